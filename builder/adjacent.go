@@ -0,0 +1,67 @@
+package builder
+
+import "fmt"
+
+// adjacentPosts are the chronological neighbours of a post.
+type adjacentPosts struct {
+	prev *PostMetadata // older
+	next *PostMetadata // newer
+}
+
+// buildAdjacent finds the neighbours of every post in the sorted (newest
+// first) list. scope limits neighbours to posts in the same "section" or
+// sharing at least one "tag"; empty means every post. Posts without tags have
+// no neighbours in the tag scope.
+func buildAdjacent(posts PostList, scope string) (map[string]adjacentPosts, error) {
+	// Groups of indexes into posts, newest first.
+	groups := map[string][]int{}
+	switch scope {
+	case "", "section":
+		for i, post := range posts {
+			key := ""
+			if scope == "section" && post.Section != nil {
+				key = post.Section.Name
+			}
+			groups[key] = append(groups[key], i)
+		}
+	case "tag":
+		for i, post := range posts {
+			for _, tag := range post.Tags {
+				groups[tagSlug(tag)] = append(groups[tagSlug(tag)], i)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("PrevNextScope: unknown scope %q, want section or tag", scope)
+	}
+
+	// A post in several groups (one per tag) gets the nearest older and newer
+	// post over all of them.
+	prev := make([]int, len(posts))
+	next := make([]int, len(posts))
+	for i := range posts {
+		prev[i], next[i] = -1, -1
+	}
+	for _, group := range groups {
+		for j, i := range group {
+			if j < len(group)-1 && group[j+1] != i && (prev[i] < 0 || group[j+1] < prev[i]) {
+				prev[i] = group[j+1]
+			}
+			if j > 0 && group[j-1] != i && group[j-1] > next[i] {
+				next[i] = group[j-1]
+			}
+		}
+	}
+
+	adjacent := map[string]adjacentPosts{}
+	for i, post := range posts {
+		var neighbours adjacentPosts
+		if prev[i] >= 0 {
+			neighbours.prev = &posts[prev[i]]
+		}
+		if next[i] >= 0 {
+			neighbours.next = &posts[next[i]]
+		}
+		adjacent[post.Slug] = neighbours
+	}
+	return adjacent, nil
+}
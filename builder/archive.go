@@ -0,0 +1,146 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Archive is handed to archive.html for the /archive/ overview as well as
+// every /<year>/ and /<year>/<month>/ page. Kind is "overview", "year" or
+// "month"; Month is only set on month pages.
+type Archive struct {
+	Kind  string
+	Year  int
+	Month time.Month
+	URL   string
+	Posts PostList
+	Years []ArchiveYear // Every year with its months, newest first.
+}
+
+type ArchiveYear struct {
+	Year   int
+	URL    string
+	Count  int
+	Months []ArchiveMonth
+}
+
+type ArchiveMonth struct {
+	Year  int
+	Month time.Month
+	URL   string
+	Count int
+}
+
+func archiveYearURL(year int) string {
+	return fmt.Sprintf("/%d/", year)
+}
+
+func archiveMonthURL(year int, month time.Month) string {
+	return fmt.Sprintf("/%d/%02d/", year, int(month))
+}
+
+// StartArchivePageBuilder groups posts by year and month and writes an
+// archive page for each, plus the /archive/ overview. Posts without a valid
+// date are left out. Nothing is generated when archive.html is missing.
+func (b *Builder) StartArchivePageBuilder(posts PostList) {
+	defer b.staticFilesCreated.Done()
+
+	if b.archiveTemplate == nil {
+		return
+	}
+
+	type monthKey struct {
+		year  int
+		month time.Month
+	}
+
+	dated := PostList{}
+	yearMap := map[int]PostList{}
+	monthMap := map[monthKey]PostList{}
+	for post := range posts.Iterator() {
+		if post.PublishedAt.IsZero() {
+			continue
+		}
+		dated = append(dated, post)
+		year := post.PublishedAt.Year()
+		key := monthKey{year, post.PublishedAt.Month()}
+		yearMap[year] = append(yearMap[year], post)
+		monthMap[key] = append(monthMap[key], post)
+	}
+
+	years := []ArchiveYear{}
+	for year, yearPosts := range yearMap {
+		archiveYear := ArchiveYear{
+			Year:  year,
+			URL:   archiveYearURL(year),
+			Count: len(yearPosts),
+		}
+		for key, monthPosts := range monthMap {
+			if key.year != year {
+				continue
+			}
+			archiveYear.Months = append(archiveYear.Months, ArchiveMonth{
+				Year:  year,
+				Month: key.month,
+				URL:   archiveMonthURL(year, key.month),
+				Count: len(monthPosts),
+			})
+		}
+		sort.Slice(archiveYear.Months, func(i, j int) bool {
+			return archiveYear.Months[i].Month > archiveYear.Months[j].Month
+		})
+		years = append(years, archiveYear)
+	}
+	sort.Slice(years, func(i, j int) bool {
+		return years[i].Year > years[j].Year
+	})
+
+	b.buildArchiveHTML(Archive{
+		Kind:  "overview",
+		URL:   "/archive/",
+		Posts: dated,
+		Years: years,
+	})
+	for year, yearPosts := range yearMap {
+		b.buildArchiveHTML(Archive{
+			Kind:  "year",
+			Year:  year,
+			URL:   archiveYearURL(year),
+			Posts: yearPosts,
+			Years: years,
+		})
+	}
+	for key, monthPosts := range monthMap {
+		b.buildArchiveHTML(Archive{
+			Kind:  "month",
+			Year:  key.year,
+			Month: key.month,
+			URL:   archiveMonthURL(key.year, key.month),
+			Posts: monthPosts,
+			Years: years,
+		})
+	}
+}
+
+func (b *Builder) buildArchiveHTML(archive Archive) {
+	var doc bytes.Buffer
+	err := b.archiveTemplate.Execute(&doc, archive)
+	if err != nil {
+		panic(err)
+	}
+
+	b.sitemap.AddPageURL(archive.URL)
+
+	outPath := outputPathForSlug(archive.URL)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(outPath, doc.Bytes(), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/kvizdos/easyblog/sitemap"
+	"github.com/yuin/goldmark"
 )
 
 type Post struct {
@@ -26,14 +28,35 @@ type Post struct {
 	Body         template.HTML
 	HTML         []byte
 	Date         string
+	PublishedAt  time.Time // Date parsed; zero when Date is not a valid date
+	UpdatedAt    time.Time // Updated front matter, or the last commit with GitDates; may be zero
 	Summary      string
 	Slug         string
 	OGImageURL   string
 	Author       string
 	Syndications map[string]string
 	Tags         []string
+	Section      *Section // nil for posts directly inside posts/
 	ToC          template.HTML
 	RawMetadata  map[string]any
+
+	SeriesName     string
+	SeriesOrder    int
+	Series         *Series // nil unless the post has a Series
+	SeriesPosition int     // 1-based position within Series
+	SeriesPrev     *PostMetadata
+	SeriesNext     *PostMetadata
+
+	Prev *PostMetadata // The next older post, see Config.PrevNextScope
+	Next *PostMetadata // The next newer post
+
+	Related   PostList // See Config.Related
+	Backlinks PostList // Posts linking here with [[wiki links]]
+
+	bundleDir  string   // Source directory of a page bundle, empty for single-file posts.
+	sourceFile string   // Path relative to the input directory, used in build errors.
+	wikiLinks  []string // Targets of the post's [[wiki links]]
+	shortcodes []shortcodeCall
 }
 
 type PostMetadata struct {
@@ -42,9 +65,22 @@ type PostMetadata struct {
 	Syndications map[string]string
 	Title        string
 	Date         string
+	PublishedAt  time.Time
+	UpdatedAt    time.Time
 	Summary      string
 	Author       string
 	Tags         []string
+	Section      *Section
+	SeriesName   string
+	SeriesOrder  int
+}
+
+// LastModified returns UpdatedAt, falling back to PublishedAt.
+func (p PostMetadata) LastModified() time.Time {
+	if !p.UpdatedAt.IsZero() {
+		return p.UpdatedAt
+	}
+	return p.PublishedAt
 }
 
 type PostList []PostMetadata
@@ -53,13 +89,10 @@ type PostList []PostMetadata
 func (p PostList) Len() int      { return len(p) }
 func (p PostList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 func (p PostList) Less(i, j int) bool {
-	// Use "01/02/2006" layout for parsing
-	t1, err1 := time.Parse("01/02/2006", p[i].Date)
-	t2, err2 := time.Parse("01/02/2006", p[j].Date)
-	if err1 != nil || err2 != nil {
+	if p[i].PublishedAt.IsZero() || p[j].PublishedAt.IsZero() {
 		return p[i].Date < p[j].Date
 	}
-	return t1.After(t2)
+	return p[i].PublishedAt.After(p[j].PublishedAt)
 }
 
 // Iterator returns a channel that iterates over sorted posts.
@@ -82,16 +115,40 @@ type Builder struct {
 	Config                  Config
 	CustomFuncs             template.FuncMap
 	OGGenerator             OGGeneratorFunc
+	Markdown                MarkdownExtensions
+	Shortcodes              map[string]ShortcodeFunc
 
 	setupWaitGroup sync.WaitGroup // setup things like parsing index.html, page.html
 
 	staticFilesCreated sync.WaitGroup
 
-	postTemplate  *template.Template
-	indexTemplate *template.Template
-	tagTemplate   *template.Template
-
-	sitemap *sitemap.Sitemap
+	postTemplate     *template.Template
+	indexTemplate    *template.Template
+	tagTemplate      *template.Template
+	pageTemplate     *template.Template
+	sectionTemplate  *template.Template
+	archiveTemplate  *template.Template
+	tagIndexTemplate *template.Template
+	seriesTemplate   *template.Template
+
+	shortcodeTemplates map[string]*template.Template
+
+	sitemap  *sitemap.Sitemap
+	site     *Site
+	markdown goldmark.Markdown // Shared by every document in a build
+
+	// posts and series are set once every post is parsed and sorted; post
+	// pages wait on postsSorted before rendering so they can link to others.
+	postsSorted sync.WaitGroup
+	posts       PostList
+	series      map[string]*Series
+	adjacent    map[string]adjacentPosts
+	wikiTargets map[string]PostMetadata
+
+	// buildErrors are collected across the whole build and reported together
+	// once it finishes.
+	buildErrorsMu sync.Mutex
+	buildErrors   []error
 }
 
 var (
@@ -152,7 +209,9 @@ func (b *Builder) Serve(port string) {
 					}
 					debounce = time.AfterFunc(debounceDelay, func() {
 						log.Println("Running build...")
-						b.Build()
+						if err := b.Build(); err != nil {
+							log.Println(err)
+						}
 					})
 					debounceMu.Unlock()
 				}
@@ -192,18 +251,38 @@ func (b *Builder) Serve(port string) {
 	http.ListenAndServe(":"+port, nil)
 }
 
-func (b *Builder) Build() {
+func (b *Builder) addBuildError(err error) {
+	b.buildErrorsMu.Lock()
+	b.buildErrors = append(b.buildErrors, err)
+	b.buildErrorsMu.Unlock()
+}
+
+// Build writes the site to out/. Problems found along the way, such as
+// invalid front matter or unresolved wiki links, are collected and returned
+// together once the build finishes.
+func (b *Builder) Build() error {
 	now := time.Now()
+	b.buildErrors = nil
 	b.sitemap = &sitemap.Sitemap{
 		BaseURL:    b.Config.BaseURL,
 		Pages:      []sitemap.SitemapPage{},
 		Xmlns:      "http://www.sitemaps.org/schemas/sitemap/0.9",
 		XmlnsXHTML: "http://www.w3.org/1999/xhtml",
 	}
-	b.setupWaitGroup.Add(2)
-	b.staticFilesCreated.Add(2)
+	b.site = &Site{
+		BaseURL:     b.Config.BaseURL,
+		Collections: map[string]*Collection{},
+		Sections:    map[string]*Section{},
+	}
+	b.markdown = NewMarkdown(b.Config, b.Markdown)
+	b.setupWaitGroup.Add(3)
+	b.staticFilesCreated.Add(8)
+	b.postsSorted.Add(1)
 	go b.setupHTML(b.Config.InputDirectory)
 	go b.setupOutDirectory()
+	go b.setupCollections()
+	go b.buildPages()
+	go b.buildCollections()
 
 	postsChan, metadataChan := b.scanForMarkdownFiles(b.Config.InputDirectory)
 	go b.buildIndexHTML(metadataChan)
@@ -215,9 +294,19 @@ func (b *Builder) Build() {
 
 	b.writeSitemapToDisk()
 	b.buildStaticFiles()
+
+	if b.Config.LinkCheck.OnBuild {
+		b.checkLinks(now)
+	}
+
+	if len(b.buildErrors) > 0 {
+		return fmt.Errorf("build failed with %d error(s):\n%w", len(b.buildErrors), errors.Join(b.buildErrors...))
+	}
+
 	took := time.Now().Sub(now)
 
 	fmt.Println("All done!", took)
+	return nil
 }
 func (b *Builder) setupOutDirectory() {
 	defer b.setupWaitGroup.Done()
@@ -260,13 +349,63 @@ func (b *Builder) setupOutDirectory() {
 }
 
 func (b *Builder) scanForMarkdownFiles(inputDirectory string) (<-chan Post, <-chan PostMetadata) {
-	postsDir := fmt.Sprintf("%s/posts/", inputDirectory)
-	// Find all Markdown files
-	files, err := os.ReadDir(postsDir)
+	postsDir := filepath.Join(inputDirectory, "posts")
+
+	type postFile struct {
+		fileName string
+		section  *Section
+	}
+
+	// Find all Markdown files. Every sub-folder is a section, unless it holds
+	// an index.md, in which case it is a page bundle whose other files are
+	// assets copied next to the rendered post.
+	files := []postFile{}
+	err := filepath.WalkDir(postsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == postsDir {
+			return nil
+		}
+		relPath, err := filepath.Rel(postsDir, path)
+		if err != nil {
+			return err
+		}
+		section := b.site.Sections[filepath.ToSlash(filepath.Dir(relPath))]
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if fileExists(filepath.Join(path, "index.md")) {
+				files = append(files, postFile{filepath.Join(relPath, "index.md"), section})
+				return filepath.SkipDir
+			}
+			section, err := loadSection(path, relPath)
+			if err != nil {
+				return err
+			}
+			b.site.Sections[section.Name] = section
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".md") {
+			files = append(files, postFile{relPath, section})
+		}
+		return nil
+	})
 	if err != nil {
 		log.Fatalf("Error reading directory: %v", err)
 	}
 
+	var gitDates map[string]GitDates
+	useFileDates := false
+	if b.Config.GitDates {
+		gitDates, err = loadGitDates(postsDir)
+		if err != nil {
+			log.Printf("warning: skipping git dates, posts without dates use their modification time: %v", err)
+			useFileDates = true
+		}
+	}
+
 	postsChan := make(chan Post, 10)
 	metadataChan := make(chan PostMetadata, 10)
 	concurrentPageBuildsPool := make(chan struct{}, b.MaxConcurrentPageBuilds)
@@ -276,16 +415,25 @@ func (b *Builder) scanForMarkdownFiles(inputDirectory string) (<-chan Post, <-ch
 		var wg sync.WaitGroup
 		for _, file := range files {
 			concurrentPageBuildsPool <- struct{}{}
-			if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-				wg.Add(1)
-				go func(fileName string) {
-					defer func() {
-						<-concurrentPageBuildsPool
-						wg.Done()
-					}()
-					ParsePost(postsChan, metadataChan, b.Config, fileName)
-				}(file.Name())
-			}
+			wg.Add(1)
+			go func(file postFile) {
+				defer func() {
+					<-concurrentPageBuildsPool
+					wg.Done()
+				}()
+				dates := gitDatesFor(gitDates, filepath.Join(postsDir, file.fileName))
+				if useFileDates {
+					dates = fileDates(filepath.Join(postsDir, file.fileName))
+				}
+				err := ParsePost(postsChan, metadataChan, b.markdown, b.Config, file.fileName, file.section, dates)
+				if joined, ok := err.(interface{ Unwrap() []error }); ok {
+					for _, err := range joined.Unwrap() {
+						b.addBuildError(err)
+					}
+				} else if err != nil {
+					b.addBuildError(err)
+				}
+			}(file)
 		}
 		wg.Wait()
 	}()
@@ -306,75 +454,153 @@ func (b *Builder) buildIndexHTML(metadata <-chan PostMetadata) {
 
 	for meta := range metadata {
 		out = append(out, meta)
-		b.sitemap.AddPageURL(meta.Slug)
+		b.sitemap.AddPageURLWithLastMod(meta.Slug, meta.LastModified())
 	}
 
 	b.setupWaitGroup.Wait()
 
 	sort.Sort(out)
 
-	go b.StartTagPageBuilder(out)
-
-	var doc bytes.Buffer
-	err := b.indexTemplate.Execute(&doc, out)
+	b.posts = out
+	b.series = buildSeries(out)
+	adjacent, err := buildAdjacent(out, b.Config.PrevNextScope)
 	if err != nil {
-		panic(err)
+		b.addBuildError(err)
+	}
+	b.adjacent = adjacent
+	b.wikiTargets = buildWikiTargets(out)
+	for _, collection := range b.site.Collections {
+		for i, item := range collection.Items {
+			sourceFile := filepath.Join(collection.config.Directory, item.OGName+".md")
+			body := b.expandShortcodes(sourceFile, item.Body, item.shortcodes, nil, nil)
+			collection.Items[i].Body = b.resolveWikiLinks(sourceFile, body)
+		}
 	}
+	b.postsSorted.Done()
 
-	err = os.WriteFile("./out/index.html", doc.Bytes(), 0644)
-	if err != nil {
-		panic(err)
+	go b.StartSeriesPageBuilder(b.series)
+	go b.StartTagPageBuilder(out)
+	go b.StartSectionPageBuilder(out)
+	go b.StartArchivePageBuilder(out)
+	go b.StartTaxonomyPageBuilder(out)
+
+	if b.Config.PageSize <= 0 {
+		var doc bytes.Buffer
+		err := b.indexTemplate.Execute(&doc, out)
+		if err != nil {
+			panic(err)
+		}
+
+		err = os.WriteFile("./out/index.html", doc.Bytes(), 0644)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		// With pagination enabled, index.html receives the same data as
+		// tag.html: the posts on the page and the paginator.
+		type indexData struct {
+			Posts     PostList
+			Paginator *Paginator
+		}
+		for _, page := range paginate(out, b.Config.PageSize, "/") {
+			var doc bytes.Buffer
+			err := b.indexTemplate.Execute(&doc, indexData{Posts: page.Items, Paginator: page})
+			if err != nil {
+				panic(err)
+			}
+			b.writePaginatedPage(page, doc.Bytes())
+		}
 	}
 
 	b.staticFilesCreated.Done()
 }
 
+// writePaginatedPage writes a page produced by paginate. Every page after the
+// first is added to the sitemap; the first one is added by its caller.
+func (b *Builder) writePaginatedPage(page *Paginator, html []byte) {
+	outPath := outputPathForSlug(page.URL)
+	if page.PageNumber > 1 {
+		b.sitemap.AddPageURL(page.URL)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(outPath, html, 0644); err != nil {
+		panic(err)
+	}
+}
+
 func (b *Builder) StartTagPageBuilder(posts PostList) {
-	tagMap := map[string]PostList{}
-
-	for post := range posts.Iterator() {
-		for _, tag := range post.Tags {
-			if v, ok := tagMap[tag]; ok {
-				tagMap[tag] = append(v, post)
-			} else {
-				tagMap[tag] = PostList{post}
-			}
-		}
+	tagMap := groupByTerm(posts, func(post PostMetadata) []string {
+		return post.Tags
+	})
+
+	tagDetails, err := loadTagData(b.Config.InputDirectory)
+	if err != nil {
+		panic(err)
 	}
 
+	tags := []Tag{}
 	var wg sync.WaitGroup
 	wg.Add(len(tagMap))
 	for tagName, taggedPosts := range tagMap {
+		tag := tagInfo(tagDetails, tagName, len(taggedPosts))
+		tags = append(tags, tag)
 		go func() {
 			defer wg.Done()
-			b.buildTagHTML(tagName, taggedPosts)
+			b.buildTagHTML(tag, taggedPosts)
 		}()
 	}
 	wg.Wait()
+
+	b.buildTagIndexHTML(tags)
+
 	b.staticFilesCreated.Done()
 }
 
-func (b *Builder) buildTagHTML(tagName string, taggedPosts PostList) {
-	var doc bytes.Buffer
-	data := struct {
-		Tag   string
-		Posts PostList
-	}{
-		Tag:   tagName,
-		Posts: taggedPosts,
-	}
-	err := b.tagTemplate.Execute(&doc, data)
-	if err != nil {
-		panic(err)
+func (b *Builder) buildTagHTML(tag Tag, taggedPosts PostList) {
+	tagName := tag.Name
+	urlTag := tagSlug(tagName)
+	b.sitemap.AddPageURL(tag.URL)
+
+	type tagData struct {
+		Tag       string
+		TagInfo   Tag
+		Posts     PostList
+		Paginator *Paginator // nil unless PageSize is set
 	}
 
-	urlTag := strings.ToLower(tagName)
-	urlTag = strings.ReplaceAll(urlTag, " ", "-")
-	b.sitemap.AddPageURL(fmt.Sprintf("/tags/%s", urlTag))
+	if b.Config.PageSize <= 0 {
+		var doc bytes.Buffer
+		err := b.tagTemplate.Execute(&doc, tagData{
+			Tag:     tagName,
+			TagInfo: tag,
+			Posts:   taggedPosts,
+		})
+		if err != nil {
+			panic(err)
+		}
 
-	err = os.WriteFile(fmt.Sprintf("./out/tags/%s.html", urlTag), doc.Bytes(), 0644)
-	if err != nil {
-		panic(err)
+		err = os.WriteFile(fmt.Sprintf("./out/tags/%s.html", urlTag), doc.Bytes(), 0644)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	for _, page := range paginate(taggedPosts, b.Config.PageSize, tag.URL) {
+		var doc bytes.Buffer
+		err := b.tagTemplate.Execute(&doc, tagData{
+			Tag:       tagName,
+			TagInfo:   tag,
+			Posts:     page.Items,
+			Paginator: page,
+		})
+		if err != nil {
+			panic(err)
+		}
+		b.writePaginatedPage(page, doc.Bytes())
 	}
 }
 
@@ -389,8 +615,31 @@ func (b *Builder) buildPostHTML(posts <-chan Post) <-chan Post {
 	outCh := make(chan Post, 10)
 	go func() {
 		defer close(outCh)
-		b.setupWaitGroup.Wait()
+
+		// Posts are only rendered once the full, sorted list exists so each
+		// one can reference the others.
+		parsed := []Post{}
 		for post := range posts {
+			parsed = append(parsed, post)
+		}
+		b.setupWaitGroup.Wait()
+		b.postsSorted.Wait()
+
+		for i := range parsed {
+			post := &parsed[i]
+			linkSeries(post, b.series)
+			post.Prev = b.adjacent[post.Slug].prev
+			post.Next = b.adjacent[post.Slug].next
+			post.Body = b.expandShortcodes(post.sourceFile, post.Body, post.shortcodes, post, nil)
+			post.Body = b.resolveWikiLinks(post.sourceFile, post.Body)
+		}
+		related := BuildRelated(parsed, b.posts, b.Config.Related)
+		backlinks := b.buildBacklinks(parsed)
+
+		for _, post := range parsed {
+			post.Related = related[post.Slug]
+			post.Backlinks = backlinks[post.Slug]
+
 			var doc bytes.Buffer
 			err := b.postTemplate.Execute(&doc, post)
 			if err != nil {
@@ -409,33 +658,47 @@ func (b *Builder) writePostOut(posts <-chan Post) {
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			err := os.WriteFile(fmt.Sprintf("./out/post/%s.html", post.OGName), post.HTML, 0644)
+			outPath := outputPathForSlug(post.Slug)
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				panic(err)
+			}
+			err := os.WriteFile(outPath, post.HTML, 0644)
 			if err != nil {
 				panic(err)
 			}
+			if post.bundleDir != "" {
+				if err := copyBundleAssets(post.bundleDir, filepath.Dir(outPath)); err != nil {
+					panic(fmt.Sprintf("error copying bundle assets: %v", err))
+				}
+			}
 		}()
 		go func() {
 			defer wg.Done()
-			if b.OGGenerator != nil {
-				b.OGGenerator(post.Title, fmt.Sprintf("./out/og_images/%s.png", post.OGName), b.Config.OGImageConfig)
-			} else {
-				GenerateOG(post.Title, fmt.Sprintf("./out/og_images/%s.png", post.OGName), b.Config.OGImageConfig)
-			}
+			b.generateOG(post.Title, fmt.Sprintf("./out/og_images/%s.png", post.OGName))
 		}()
 	}
 	wg.Wait()
 }
 
+func (b *Builder) generateOG(title string, outPath string) {
+	if b.OGGenerator != nil {
+		b.OGGenerator(title, outPath, b.Config.OGImageConfig)
+	} else {
+		GenerateOG(title, outPath, b.Config.OGImageConfig)
+	}
+}
+
 func (b *Builder) getFuncsMap() template.FuncMap {
 	out := template.FuncMap{
-		"TagToURL": func(inp string) string {
-			out := strings.ToLower(inp)
-			out = strings.ReplaceAll(out, " ", "-")
-			return out
-		},
+		"TagToURL": tagSlug,
 		"contains": func(slice []string, item string) bool {
 			return slices.Contains(slice, item)
 		},
+		"site": func() *Site {
+			return b.site
+		},
+		"formatDate":     formatDate,
+		"structuredData": b.structuredData,
 	}
 
 	maps.Copy(out, b.CustomFuncs)
@@ -447,5 +710,38 @@ func (b *Builder) setupHTML(inputDirectory string) {
 	b.indexTemplate = template.Must(template.New("index.html").Funcs(b.getFuncsMap()).ParseFiles(fmt.Sprintf("%s/templates/index.html", inputDirectory)))
 	b.tagTemplate = template.Must(template.New("tag.html").Funcs(b.getFuncsMap()).ParseFiles(fmt.Sprintf("%s/templates/tag.html", inputDirectory)))
 
+	// page.html is optional; it is only required when pages/ exists.
+	b.pageTemplate = nil
+	if pagePath := fmt.Sprintf("%s/templates/page.html", inputDirectory); fileExists(pagePath) {
+		b.pageTemplate = template.Must(template.New("page.html").Funcs(b.getFuncsMap()).ParseFiles(pagePath))
+	}
+
+	// section.html is optional; section listing pages are only generated when it exists.
+	b.sectionTemplate = nil
+	if sectionPath := fmt.Sprintf("%s/templates/section.html", inputDirectory); fileExists(sectionPath) {
+		b.sectionTemplate = template.Must(template.New("section.html").Funcs(b.getFuncsMap()).ParseFiles(sectionPath))
+	}
+
+	// tags.html is optional; out/tags/index.html is only generated when it exists.
+	b.tagIndexTemplate = nil
+	if tagIndexPath := fmt.Sprintf("%s/templates/tags.html", inputDirectory); fileExists(tagIndexPath) {
+		b.tagIndexTemplate = template.Must(template.New("tags.html").Funcs(b.getFuncsMap()).ParseFiles(tagIndexPath))
+	}
+
+	// series.html is optional; it is only required when posts use Series.
+	b.seriesTemplate = nil
+	if seriesPath := fmt.Sprintf("%s/templates/series.html", inputDirectory); fileExists(seriesPath) {
+		b.seriesTemplate = template.Must(template.New("series.html").Funcs(b.getFuncsMap()).ParseFiles(seriesPath))
+	}
+
+	// archive.html is optional; date archives are only generated when it exists.
+	b.archiveTemplate = nil
+	if archivePath := fmt.Sprintf("%s/templates/archive.html", inputDirectory); fileExists(archivePath) {
+		b.archiveTemplate = template.Must(template.New("archive.html").Funcs(b.getFuncsMap()).ParseFiles(archivePath))
+	}
+
+	// templates/shortcodes/<name>.html back the {{< name >}} shortcodes.
+	b.shortcodeTemplates = b.loadShortcodeTemplates(inputDirectory)
+
 	b.setupWaitGroup.Done()
 }
@@ -0,0 +1,260 @@
+package builder
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Callouts are written GitHub style, as a blockquote starting with a type
+// marker:
+//
+//	> [!WARNING] Optional title
+//	> Body
+//
+// or as a fenced block, closed by a line of at least as many colons:
+//
+//	:::tip Optional title
+//	Body
+//	:::
+//
+// Both become a Callout, rendered as
+//
+//	<aside class="callout callout-tip" role="note">
+//	<p class="callout-title"><span class="callout-icon" aria-hidden="true"></span>Tip</p>
+//	<div class="callout-body">...</div>
+//	</aside>
+
+// defaultCalloutTypes maps each callout type to its default title.
+var defaultCalloutTypes = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+}
+
+// calloutTypes returns the default callout types with
+// Markdown.CalloutTypes merged over them. A type with an empty label is
+// turned off.
+func (c Config) calloutTypes() map[string]string {
+	types := map[string]string{}
+	for name, label := range defaultCalloutTypes {
+		types[name] = label
+	}
+	for name, label := range c.Markdown.CalloutTypes {
+		name = strings.ToLower(name)
+		if label == "" {
+			delete(types, name)
+			continue
+		}
+		types[name] = label
+	}
+	return types
+}
+
+var KindCallout = ast.NewNodeKind("Callout")
+
+// Callout is a block of a given CalloutType (note, tip, ...) with a Title.
+type Callout struct {
+	ast.BaseBlock
+	CalloutType string
+	Title       string
+
+	fenceLength int // Number of colons opening a fenced callout
+}
+
+func (n *Callout) Kind() ast.NodeKind { return KindCallout }
+
+func (n *Callout) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"CalloutType": n.CalloutType, "Title": n.Title}, nil)
+}
+
+type calloutParser struct {
+	types map[string]string
+}
+
+func (p *calloutParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p *calloutParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || line[pos] != ':' {
+		return nil, parser.NoChildren
+	}
+	i := pos
+	for i < len(line) && line[i] == ':' {
+		i++
+	}
+	if i-pos < 3 {
+		return nil, parser.NoChildren
+	}
+
+	// Only known types are callouts; anything else (e.g. :::{.class}) is
+	// left to the fences extension.
+	info := strings.TrimSpace(string(line[i:]))
+	calloutType, title, _ := strings.Cut(info, " ")
+	calloutType = strings.ToLower(calloutType)
+	label, ok := p.types[calloutType]
+	if !ok {
+		return nil, parser.NoChildren
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = label
+	}
+
+	reader.Advance(segment.Len() - 1)
+	return &Callout{CalloutType: calloutType, Title: title, fenceLength: i - pos}, parser.HasChildren
+}
+
+func (p *calloutParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	w, pos := util.IndentWidth(line, reader.LineOffset())
+	if w < 4 {
+		i := pos
+		for i < len(line) && line[i] == ':' {
+			i++
+		}
+		if i-pos >= node.(*Callout).fenceLength && util.IsBlank(line[i:]) {
+			newline := 1
+			if line[len(line)-1] != '\n' {
+				newline = 0
+			}
+			reader.Advance(segment.Stop - segment.Start - newline + segment.Padding)
+			return parser.Close
+		}
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (p *calloutParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *calloutParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *calloutParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+var calloutMarkerPattern = regexp.MustCompile(`^\[!([A-Za-z]+)\][ \t]*(.*?)\s*$`)
+
+// calloutTransformer turns blockquotes starting with [!TYPE] into callouts.
+type calloutTransformer struct {
+	types map[string]string
+}
+
+func (t *calloutTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	quotes := []*ast.Blockquote{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if quote, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, quote)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, quote := range quotes {
+		para, ok := quote.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		firstLine := para.Lines().At(0)
+		m := calloutMarkerPattern.FindSubmatch(bytes.TrimRight(firstLine.Value(source), "\r\n"))
+		if m == nil {
+			continue
+		}
+		calloutType := strings.ToLower(string(m[1]))
+		label, ok := t.types[calloutType]
+		if !ok {
+			continue
+		}
+		title := string(m[2])
+		if title == "" {
+			title = label
+		}
+
+		// Drop the inline nodes of the marker line.
+		for child := para.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			start, ok := inlineStart(child)
+			if ok && start >= firstLine.Stop {
+				break
+			}
+			para.RemoveChild(para, child)
+			child = next
+		}
+		if para.ChildCount() == 0 {
+			quote.RemoveChild(quote, para)
+		}
+
+		callout := &Callout{CalloutType: calloutType, Title: title}
+		for child := quote.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			callout.AppendChild(callout, child)
+			child = next
+		}
+		quote.Parent().ReplaceChild(quote.Parent(), quote, callout)
+	}
+}
+
+// inlineStart returns the source offset an inline node starts at.
+func inlineStart(n ast.Node) (int, bool) {
+	if t, ok := n.(*ast.Text); ok {
+		return t.Segment.Start, true
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if start, ok := inlineStart(child); ok {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+type calloutRenderer struct{}
+
+func (r *calloutRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindCallout, r.render)
+}
+
+func (r *calloutRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*Callout)
+	if !entering {
+		_, _ = w.WriteString("</div>\n</aside>\n")
+		return ast.WalkContinue, nil
+	}
+	calloutType := html.EscapeString(n.CalloutType)
+	_, _ = w.WriteString(`<aside class="callout callout-` + calloutType + `" role="note">` + "\n")
+	_, _ = w.WriteString(`<p class="callout-title"><span class="callout-icon" aria-hidden="true"></span>` + html.EscapeString(n.Title) + "</p>\n")
+	_, _ = w.WriteString(`<div class="callout-body">` + "\n")
+	return ast.WalkContinue, nil
+}
+
+type calloutExtender struct {
+	types map[string]string
+}
+
+func (e *calloutExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(&calloutParser{types: e.types}, 99), // before the fences extension
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(&calloutTransformer{types: e.types}, 100),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&calloutRenderer{}, 500),
+	))
+}
@@ -0,0 +1,27 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kvizdos/easyblog/linkcheck"
+)
+
+// checkLinks runs the link checker over the files written to out/ since
+// buildStarted and records every broken link as a build error. Older files
+// are left over from earlier builds and no longer part of the site.
+func (b *Builder) checkLinks(buildStarted time.Time) {
+	opts := b.Config.LinkCheckOptions("out")
+	// Some file systems only keep modification times to the second.
+	opts.Since = buildStarted.Truncate(time.Second)
+	report, err := linkcheck.Check(opts)
+	if err != nil {
+		b.addBuildError(fmt.Errorf("link check: %w", err))
+		return
+	}
+	report.Print(os.Stdout)
+	for _, broken := range report.Broken {
+		b.addBuildError(fmt.Errorf("out/%s: broken link %s (%s)", broken.Page, broken.URL, broken.Reason))
+	}
+}
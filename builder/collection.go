@@ -0,0 +1,243 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+// Site is exposed to every template through the `site` template func.
+type Site struct {
+	BaseURL     string
+	Collections map[string]*Collection
+	Sections    map[string]*Section
+}
+
+// Collection is a config-defined group of markdown documents (notes,
+// projects, talks) that are rendered separately from posts.
+type Collection struct {
+	Name  string
+	Feed  bool
+	Items []CollectionItem
+
+	config   CollectionConfig
+	template *template.Template
+}
+
+type CollectionItem struct {
+	Collection  string
+	Title       string
+	OGName      string
+	Body        template.HTML
+	Date        string
+	PublishedAt time.Time
+	Summary     string
+	Slug        string
+	ToC         template.HTML
+	RawMetadata map[string]any
+
+	shortcodes []shortcodeCall
+}
+
+// setupCollections parses every configured collection so that templates can
+// reference them through `site` before any page is rendered.
+func (b *Builder) setupCollections() {
+	defer b.setupWaitGroup.Done()
+
+	for _, collectionConfig := range b.Config.Collections {
+		collection := &Collection{
+			Name:   collectionConfig.Name,
+			Feed:   collectionConfig.Feed,
+			config: collectionConfig,
+		}
+
+		templatePath := filepath.Join(b.Config.InputDirectory, "templates", collectionConfig.Template)
+		collection.template = template.Must(template.New(collectionConfig.Template).Funcs(b.getFuncsMap()).ParseFiles(templatePath))
+
+		sourceDir := filepath.Join(b.Config.InputDirectory, collectionConfig.Directory)
+		files, err := os.ReadDir(sourceDir)
+		if err != nil {
+			panic(fmt.Sprintf("error reading collection %q: %v", collectionConfig.Name, err))
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+				continue
+			}
+			item, err := parseCollectionItem(b.markdown, b.Config, collectionConfig, file.Name())
+			if err != nil {
+				b.addBuildError(err)
+				continue
+			}
+			collection.Items = append(collection.Items, item)
+		}
+
+		sortCollectionItems(b.Config, collection.Items, collectionConfig.SortBy, collectionConfig.Descending)
+
+		b.site.Collections[collection.Name] = collection
+	}
+}
+
+func parseCollectionItem(md goldmark.Markdown, config Config, collectionConfig CollectionConfig, fileName string) (CollectionItem, error) {
+	itemMd, err := os.ReadFile(filepath.Join(config.InputDirectory, collectionConfig.Directory, fileName))
+	if err != nil {
+		return CollectionItem{}, err
+	}
+
+	rendered, err := renderMarkdown(md, itemMd, collectionConfig.Name+"-"+strings.TrimSuffix(fileName, ".md")+"-")
+	if err != nil {
+		return CollectionItem{}, fmt.Errorf("%s: %w", filepath.Join(collectionConfig.Directory, fileName), err)
+	}
+	logWarnings(filepath.Join(collectionConfig.Directory, fileName), rendered.Warnings)
+	metaData := rendered.Metadata
+
+	strippedFileName := strings.TrimSuffix(fileName, ".md")
+
+	item := CollectionItem{
+		Collection:  collectionConfig.Name,
+		Title:       strings.ReplaceAll(strippedFileName, "-", " "),
+		OGName:      strippedFileName,
+		Body:        rendered.Body,
+		ToC:         rendered.ToC,
+		RawMetadata: metaData,
+		shortcodes:  rendered.Shortcodes,
+	}
+	if v, ok := metaData["Title"].(string); ok {
+		item.Title = v
+	}
+	item.Date = dateString(metaData["Date"])
+	item.PublishedAt, _ = config.ParseDate(metaData["Date"])
+	if v, ok := metaData["Summary"].(string); ok {
+		item.Summary = v
+	}
+
+	item.Slug = expandPermalink(collectionConfig.Permalink, item)
+
+	return item, nil
+}
+
+// expandPermalink replaces :collection, :slug, :year, :month and :day in the
+// configured pattern. The default pattern is /:collection/:slug.
+func expandPermalink(pattern string, item CollectionItem) string {
+	if pattern == "" {
+		pattern = "/:collection/:slug"
+	}
+
+	year, month, day := "", "", ""
+	if t := item.PublishedAt; !t.IsZero() {
+		year = t.Format("2006")
+		month = t.Format("01")
+		day = t.Format("02")
+	}
+
+	return strings.NewReplacer(
+		":collection", item.Collection,
+		":slug", item.OGName,
+		":year", year,
+		":month", month,
+		":day", day,
+	).Replace(pattern)
+}
+
+// sortCollectionItems orders items by the front matter key sortBy. Dates and
+// numbers are compared by value, everything else as strings.
+func sortCollectionItems(config Config, items []CollectionItem, sortBy string, descending bool) {
+	if sortBy == "" {
+		sortBy = "Date"
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if descending {
+			return lessMetadataValue(config, items[j].RawMetadata[sortBy], items[i].RawMetadata[sortBy])
+		}
+		return lessMetadataValue(config, items[i].RawMetadata[sortBy], items[j].RawMetadata[sortBy])
+	})
+}
+
+func lessMetadataValue(config Config, a any, b any) bool {
+	t1, err1 := config.ParseDate(a)
+	t2, err2 := config.ParseDate(b)
+	if err1 == nil && err2 == nil {
+		return t1.Before(t2)
+	}
+
+	aStr := fmt.Sprint(a)
+	bStr := fmt.Sprint(b)
+
+	f1, err1 := strconv.ParseFloat(aStr, 64)
+	f2, err2 := strconv.ParseFloat(bStr, 64)
+	if err1 == nil && err2 == nil {
+		return f1 < f2
+	}
+
+	return aStr < bStr
+}
+
+// buildCollections renders every item of every collection through the
+// collection's template.
+func (b *Builder) buildCollections() {
+	defer b.staticFilesCreated.Done()
+
+	b.setupWaitGroup.Wait()
+	b.postsSorted.Wait() // wiki links are resolved once posts are sorted
+
+	var wg sync.WaitGroup
+	for _, collection := range b.site.Collections {
+		for _, item := range collection.Items {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				var doc bytes.Buffer
+				if err := collection.template.Execute(&doc, item); err != nil {
+					panic(err)
+				}
+
+				outPath := outputPathForSlug(item.Slug)
+				if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+					panic(err)
+				}
+				if err := os.WriteFile(outPath, doc.Bytes(), 0644); err != nil {
+					panic(err)
+				}
+
+				if collection.config.Sitemap {
+					b.sitemap.AddPageURL(item.Slug)
+				}
+			}()
+		}
+
+		if collection.Feed {
+			b.writeFeed(collection.Name, "/"+collection.Name, "/"+collection.Name+".xml", collection.feedPosts())
+		}
+	}
+	wg.Wait()
+}
+
+// feedPosts returns the items of the collection as posts for writeFeed,
+// newest first.
+func (c *Collection) feedPosts() PostList {
+	posts := PostList{}
+	for _, item := range c.Items {
+		posts = append(posts, PostMetadata{
+			Title:       item.Title,
+			Slug:        item.Slug,
+			Summary:     item.Summary,
+			Author:      stringField(item.RawMetadata, "Author"),
+			PublishedAt: item.PublishedAt,
+		})
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].PublishedAt.After(posts[j].PublishedAt)
+	})
+	return posts
+}
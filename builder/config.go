@@ -1,5 +1,13 @@
 package builder
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kvizdos/easyblog/linkcheck"
+)
+
 type OGImageConfig struct {
 	IconPath string  `yaml:"IconPath"`
 	FontPath string  `yaml:"FontPath"`
@@ -16,10 +24,124 @@ type StaticConfig struct {
 	Path string `yaml:"Path"`
 }
 
+// CollectionConfig declares a content collection read from
+// <input>/<Directory>/*.md and rendered with templates/<Template>.
+type CollectionConfig struct {
+	Name       string `yaml:"Name"`
+	Directory  string `yaml:"Directory"`
+	Template   string `yaml:"Template"`
+	Permalink  string `yaml:"Permalink"` // e.g. /notes/:slug or /talks/:year/:slug
+	SortBy     string `yaml:"SortBy"`    // Front matter key, defaults to Date
+	Descending bool   `yaml:"Descending"`
+	Sitemap    bool   `yaml:"Sitemap"`
+	Feed       bool   `yaml:"Feed"` // Write an Atom feed of the items at /<Name>.xml
+}
+
+// TaxonomyConfig declares a taxonomy read from the front matter field Name
+// (e.g. Categories). Each term is published at /<Path>/<term>, rendered with
+// Template (default term.html), and the term list at /<Path>/ with
+// IndexTemplate (default terms.html).
+type TaxonomyConfig struct {
+	Name          string `yaml:"Name"`
+	Path          string `yaml:"Path"` // Defaults to the lower-cased Name
+	Template      string `yaml:"Template"`
+	IndexTemplate string `yaml:"IndexTemplate"`
+	Feed          bool   `yaml:"Feed"` // Write an Atom feed per term at /<Path>/<term>.xml
+}
+
+func (t TaxonomyConfig) basePath() string {
+	if t.Path != "" {
+		return "/" + strings.Trim(t.Path, "/")
+	}
+	return "/" + tagSlug(t.Name)
+}
+
+// reservedPaths are the top-level paths the builder publishes itself.
+var reservedPaths = []string{"/post", "/tags", "/series", "/archive", "/page", "/assets", "/og_images"}
+
+// checkTaxonomyPaths reports taxonomies published under a path the builder
+// already uses, or under the same path as another taxonomy; their pages
+// would overwrite each other.
+func (c Config) checkTaxonomyPaths() []error {
+	errs := []error{}
+	used := map[string]string{}
+	for _, taxonomy := range c.Taxonomies {
+		basePath := taxonomy.basePath()
+		for _, reserved := range reservedPaths {
+			if basePath == reserved || strings.HasPrefix(basePath, reserved+"/") {
+				errs = append(errs, fmt.Errorf("Taxonomies: %s would be published under %s, which is reserved; set its Path", taxonomy.Name, basePath))
+			}
+		}
+		if other, ok := used[basePath]; ok {
+			errs = append(errs, fmt.Errorf("Taxonomies: %s and %s are both published under %s; set a different Path", other, taxonomy.Name, basePath))
+		}
+		used[basePath] = taxonomy.Name
+	}
+	return errs
+}
+
+// RelatedConfig controls Post.Related. Posts are scored by
+// TagWeight * shared tags + ContentWeight * body text similarity (0 to 1).
+type RelatedConfig struct {
+	Count         int     `yaml:"Count"` // 0 disables related posts
+	TagWeight     float64 `yaml:"TagWeight"`
+	ContentWeight float64 `yaml:"ContentWeight"`
+}
+
+// LinkCheckConfig controls `easyblog check-links` and, with OnBuild, a link
+// check after every build that fails the build on broken links.
+type LinkCheckConfig struct {
+	OnBuild      bool     `yaml:"OnBuild"`
+	External     bool     `yaml:"External"`    // Also request external URLs
+	Concurrency  int      `yaml:"Concurrency"` // Parallel external requests, defaults to 8
+	Timeout      string   `yaml:"Timeout"`     // Per request, e.g. 10s
+	AllowDomains []string `yaml:"AllowDomains"`
+	DenyDomains  []string `yaml:"DenyDomains"`
+	CacheFile    string   `yaml:"CacheFile"` // e.g. .linkcheck-cache.json
+	CacheTTL     string   `yaml:"CacheTTL"`  // e.g. 24h
+}
+
+// LinkCheckOptions converts the config into linkcheck options for the site in dir.
+func (c Config) LinkCheckOptions(dir string) linkcheck.Options {
+	parse := func(field string, value string) time.Duration {
+		if value == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			panic(fmt.Sprintf("LinkCheck.%s: %v", field, err))
+		}
+		return d
+	}
+	return linkcheck.Options{
+		Dir:          dir,
+		BaseURL:      c.BaseURL,
+		External:     c.LinkCheck.External,
+		Concurrency:  c.LinkCheck.Concurrency,
+		Timeout:      parse("Timeout", c.LinkCheck.Timeout),
+		AllowDomains: c.LinkCheck.AllowDomains,
+		DenyDomains:  c.LinkCheck.DenyDomains,
+		CacheFile:    c.LinkCheck.CacheFile,
+		CacheTTL:     parse("CacheTTL", c.LinkCheck.CacheTTL),
+	}
+}
+
 type Config struct {
-	InputDirectory string        `yaml:"InputDirectory"`
-	BaseURL        string        `yaml:"BaseURL"`
-	OGImageConfig  OGImageConfig `yaml:"OGImageConfig"`
-	CodeStyle      string        `yaml:"CodeStyle"` // Chroma Style
-	StaticConfig   StaticConfig  `yaml:"StaticConfig"`
+	InputDirectory string                 `yaml:"InputDirectory"`
+	BaseURL        string                 `yaml:"BaseURL"`
+	OGImageConfig  OGImageConfig          `yaml:"OGImageConfig"`
+	CodeStyle      string                 `yaml:"CodeStyle"` // Chroma Style
+	StaticConfig   StaticConfig           `yaml:"StaticConfig"`
+	Collections    []CollectionConfig     `yaml:"Collections"`
+	PageSize       int                    `yaml:"PageSize"`   // Posts per index/tag page, 0 disables pagination
+	DateLayout     string                 `yaml:"DateLayout"` // Go time layout tried before the built-in ones
+	Timezone       string                 `yaml:"Timezone"`   // IANA name, e.g. America/New_York. Defaults to UTC
+	GitDates       bool                   `yaml:"GitDates"`   // Derive missing Date/Updated from the local git history
+	TagAliases     map[string]string      `yaml:"TagAliases"` // Variant spelling -> canonical tag, e.g. golang: Go
+	Taxonomies     []TaxonomyConfig       `yaml:"Taxonomies"`
+	PrevNextScope  string                 `yaml:"PrevNextScope"` // "", "section" or "tag" (a shared tag): where Post.Prev/Next are looked up
+	Related        RelatedConfig          `yaml:"Related"`
+	LinkCheck      LinkCheckConfig        `yaml:"LinkCheck"`
+	FrontMatter    map[string]FieldSchema `yaml:"FrontMatter"` // Post front matter schema, merged over the built-in fields
+	Markdown       MarkdownConfig         `yaml:"Markdown"`
 }
@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func copyDir(src string, dst string) error {
@@ -46,3 +47,40 @@ func copyFile(src, dst string) error {
 	}
 	return os.Chmod(dst, info.Mode())
 }
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// copyBundleAssets copies everything in a page bundle except its markdown
+// files into dst.
+func copyBundleAssets(bundleDir string, dst string) error {
+	return filepath.Walk(bundleDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(bundleDir, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(dst, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, 0755)
+		}
+		if strings.HasSuffix(info.Name(), ".md") {
+			return nil
+		}
+		return copyFile(path, targetPath)
+	})
+}
+
+// outputPathForSlug maps a URL path to the file it is written to: /a/b
+// becomes out/a/b.html and /a/b/ becomes out/a/b/index.html.
+func outputPathForSlug(slug string) string {
+	outPath := filepath.Join("out", slug)
+	if strings.HasSuffix(slug, "/") {
+		return filepath.Join(outPath, "index.html")
+	}
+	return outPath + ".html"
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dateLayouts are tried in order after Config.DateLayout when parsing a
+// front matter date. Layouts without an offset are read in the site timezone.
+var dateLayouts = []string{
+	"01/02/2006",
+	time.DateOnly,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	time.DateTime,
+	"2006-01-02 15:04",
+}
+
+var locationCache sync.Map
+
+// Location returns the configured site timezone, UTC when unset.
+func (c Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.UTC
+	}
+	if loc, ok := locationCache.Load(c.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		panic(fmt.Sprintf("invalid Timezone %q: %v", c.Timezone, err))
+	}
+	locationCache.Store(c.Timezone, loc)
+	return loc
+}
+
+// ParseDate parses a front matter date, which is either a string in one of
+// the supported layouts or a YAML native timestamp. The result is always in
+// the site timezone.
+func (c Config) ParseDate(value any) (time.Time, error) {
+	loc := c.Location()
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.In(loc), nil
+	case string:
+		layouts := dateLayouts
+		if c.DateLayout != "" {
+			layouts = append([]string{c.DateLayout}, dateLayouts...)
+		}
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t.In(loc), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+	case nil:
+		return time.Time{}, fmt.Errorf("missing date")
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date type %T", value)
+	}
+}
+
+// dateString returns the front matter date as it should be displayed in
+// Post.Date; YAML native timestamps are formatted as YYYY-MM-DD.
+func dateString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.DateOnly)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatDate is exposed to templates, e.g. {{ .PublishedAt | formatDate "Jan 2, 2006" }}.
+func formatDate(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
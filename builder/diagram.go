@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bep/goat"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Fenced code blocks in a diagram language are rendered to inline SVG
+// instead of being highlighted:
+//
+//	```goat
+//	+-----+     +-----+
+//	| app |---->| db  |
+//	+-----+     +-----+
+//	```
+//
+// becomes <div class="diagram diagram-goat"><svg ...>...</svg></div>. goat
+// (ASCII art) is built in; other languages, such as pikchr, are added through
+// MarkdownExtensions.Diagrams. SVGs are cached by
+// a hash of their language and source, in memory and, with
+// Markdown.DiagramCache, on disk.
+
+// DiagramFunc renders the source of a diagram to SVG.
+type DiagramFunc func(source []byte) ([]byte, error)
+
+// builtinDiagrams are the diagram languages available to every site.
+var builtinDiagrams = map[string]DiagramFunc{
+	"goat": func(source []byte) ([]byte, error) {
+		return []byte(goat.BuildSVG(bytes.NewReader(source)).String()), nil
+	},
+}
+
+// diagramCache holds rendered SVGs by diagramHash for the life of the
+// process, so serve mode only renders diagrams that changed.
+var diagramCache sync.Map
+
+func diagramHash(language string, source []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(language))
+	hash.Write([]byte{0})
+	hash.Write(source)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+var KindDiagram = ast.NewNodeKind("Diagram")
+
+// Diagram is a fenced code block rendered to SVG.
+type Diagram struct {
+	ast.BaseBlock
+	Language string
+	SVG      []byte
+}
+
+func (n *Diagram) Kind() ast.NodeKind { return KindDiagram }
+
+func (n *Diagram) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Language": n.Language}, nil)
+}
+
+// diagramTransformer replaces fenced code blocks in a diagram language with
+// Diagrams. It runs before rendering, so the highlighting extension never
+// sees them.
+type diagramTransformer struct {
+	diagrams map[string]DiagramFunc
+	cacheDir string
+}
+
+func (t *diagramTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	blocks := []*ast.FencedCodeBlock{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if block, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			if _, ok := t.diagrams[string(block.Language(source))]; ok {
+				blocks = append(blocks, block)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		language := string(block.Language(source))
+		var code bytes.Buffer
+		for i := 0; i < block.Lines().Len(); i++ {
+			line := block.Lines().At(i)
+			code.Write(line.Value(source))
+		}
+
+		svg, err := t.render(language, code.Bytes())
+		if err != nil {
+			// Left as a code block, so the source still shows.
+			offset := 0
+			if block.Info != nil {
+				offset = block.Info.Segment.Start
+			}
+			addParseWarning(pc, offset, fmt.Errorf("diagram %s: %v", language, err))
+			continue
+		}
+		diagram := &Diagram{Language: language, SVG: svg}
+		block.Parent().ReplaceChild(block.Parent(), block, diagram)
+	}
+}
+
+// render returns the SVG of a diagram from the cache, rendering and caching
+// it when it isn't there.
+func (t *diagramTransformer) render(language string, source []byte) (svg []byte, err error) {
+	hash := diagramHash(language, source)
+	if cached, ok := diagramCache.Load(hash); ok {
+		return cached.([]byte), nil
+	}
+	cacheFile := ""
+	if t.cacheDir != "" {
+		cacheFile = filepath.Join(t.cacheDir, hash+".svg")
+		if cached, err := os.ReadFile(cacheFile); err == nil {
+			diagramCache.Store(hash, cached)
+			return cached, nil
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			svg, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+	svg, err = t.diagrams[language](source)
+	if err != nil {
+		return nil, err
+	}
+	diagramCache.Store(hash, svg)
+	if cacheFile != "" {
+		if err := os.MkdirAll(t.cacheDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(cacheFile, svg, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return svg, nil
+}
+
+type diagramRenderer struct{}
+
+func (r *diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindDiagram, r.render)
+}
+
+func (r *diagramRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Diagram)
+	_, _ = w.WriteString(`<div class="diagram diagram-` + html.EscapeString(n.Language) + `">` + "\n")
+	_, _ = w.Write(bytes.TrimSpace(n.SVG))
+	_, _ = w.WriteString("\n</div>\n")
+	return ast.WalkContinue, nil
+}
+
+type diagramExtender struct {
+	diagrams map[string]DiagramFunc
+	cacheDir string
+}
+
+func (e *diagramExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&diagramTransformer{diagrams: e.diagrams, cacheDir: e.cacheDir}, 100),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&diagramRenderer{}, 500),
+	))
+}
@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Footnotes ([^1] and [^1]: ...) use goldmark's footnote extension. Their
+// ids are prefixed per document (my-post-fn:1, my-post-fnref:1) so they stay
+// unique when several documents end up on one page and never clash with
+// heading ids.
+//
+// With Markdown.Sidenotes, each reference is followed by its note:
+//
+//	<sup id="my-post-fnref:1" class="sidenote-ref"><a href="#my-post-fn:1" class="footnote-ref" role="doc-noteref" aria-describedby="my-post-sn:1">1</a></sup><span class="sidenote" id="my-post-sn:1" role="note"><span class="sidenote-number">1</span> ...</span>
+//
+// which a stylesheet floats into the margin. The usual list is still
+// rendered, marked with a sidenote-fallback class, for narrow screens; its
+// items shown as sidenotes have a has-sidenote class. Notes holding more
+// than paragraphs (lists, code) don't fit inline and only appear in the list.
+
+// footnotePrefixAttribute holds the id prefix on the parsed document; see
+// renderMarkdown.
+const footnotePrefixAttribute = "footnotePrefix"
+
+// footnoteIDPrefix is the footnote extension's IDPrefixFunction.
+func footnoteIDPrefix(node ast.Node) []byte {
+	doc := node.OwnerDocument()
+	if doc == nil {
+		return nil
+	}
+	prefix, ok := doc.AttributeString(footnotePrefixAttribute)
+	if !ok {
+		return nil
+	}
+	return prefix.([]byte)
+}
+
+type sidenoteRenderer struct {
+	md goldmark.Markdown // Renders the note's content
+}
+
+func (r *sidenoteRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(east.KindFootnoteLink, r.renderLink)
+	reg.Register(east.KindFootnote, r.renderFootnote)
+	reg.Register(east.KindFootnoteList, r.renderList)
+}
+
+func (r *sidenoteRenderer) renderLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*east.FootnoteLink)
+	prefix := string(footnoteIDPrefix(node))
+	index := strconv.Itoa(n.Index)
+	refID := prefix + "fnref:" + index
+	if n.RefIndex > 0 {
+		refID = prefix + "fnref" + strconv.Itoa(n.RefIndex) + ":" + index
+	}
+	noteID := prefix + "sn:" + index
+
+	// Only the first reference to a note gets the sidenote, and only notes
+	// made of paragraphs fit inline; others are left to the list.
+	footnote := findFootnote(node.OwnerDocument(), n.Index)
+	inline := n.RefIndex == 0 && footnote != nil && onlyParagraphs(footnote)
+
+	_, _ = w.WriteString(`<sup id="` + refID + `" class="sidenote-ref"><a href="#` + prefix + "fn:" + index + `" class="footnote-ref" role="doc-noteref"`)
+	if inline {
+		_, _ = w.WriteString(` aria-describedby="` + noteID + `"`)
+	}
+	_, _ = w.WriteString(`>` + index + `</a></sup>`)
+	if !inline {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<span class="sidenote" id="` + noteID + `" role="note"><span class="sidenote-number">` + index + `</span> `)
+	for para := footnote.FirstChild(); para != nil; para = para.NextSibling() {
+		if para != footnote.FirstChild() {
+			_, _ = w.WriteString("<br>")
+		}
+		for child := para.FirstChild(); child != nil; child = child.NextSibling() {
+			if child.Kind() == east.KindFootnoteBacklink {
+				continue
+			}
+			if err := r.md.Renderer().Render(w, source, child); err != nil {
+				return ast.WalkStop, err
+			}
+		}
+	}
+	_, _ = w.WriteString(`</span>`)
+	return ast.WalkContinue, nil
+}
+
+func (r *sidenoteRenderer) renderList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<div class=\"footnotes sidenote-fallback\" role=\"doc-endnotes\">\n<hr>\n<ol>\n")
+	} else {
+		_, _ = w.WriteString("</ol>\n</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// renderFootnote marks list items shown as sidenotes, so a stylesheet can
+// hide them from the list on wide screens.
+func (r *sidenoteRenderer) renderFootnote(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*east.Footnote)
+	if !entering {
+		_, _ = w.WriteString("</li>\n")
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString(`<li id="` + string(footnoteIDPrefix(node)) + "fn:" + strconv.Itoa(n.Index) + `"`)
+	if onlyParagraphs(n) {
+		_, _ = w.WriteString(` class="has-sidenote"`)
+	}
+	_, _ = w.WriteString(">\n")
+	return ast.WalkContinue, nil
+}
+
+func findFootnote(doc *ast.Document, index int) *east.Footnote {
+	if doc == nil {
+		return nil
+	}
+	for list := doc.FirstChild(); list != nil; list = list.NextSibling() {
+		if list.Kind() != east.KindFootnoteList {
+			continue
+		}
+		for child := list.FirstChild(); child != nil; child = child.NextSibling() {
+			if footnote, ok := child.(*east.Footnote); ok && footnote.Index == index {
+				return footnote
+			}
+		}
+	}
+	return nil
+}
+
+func onlyParagraphs(footnote *east.Footnote) bool {
+	for child := footnote.FirstChild(); child != nil; child = child.NextSibling() {
+		if child.Kind() != ast.KindParagraph {
+			return false
+		}
+	}
+	return true
+}
+
+type sidenoteExtender struct{}
+
+func (e *sidenoteExtender) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&sidenoteRenderer{md: m}, 100), // over the footnote extension's renderer
+	))
+}
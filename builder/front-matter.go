@@ -0,0 +1,342 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FieldSchema declares a post front matter field. Fields declared in
+// Config.FrontMatter are merged over the built-in ones, so sites can make
+// e.g. Summary required or give Author a default.
+type FieldSchema struct {
+	Type     string   `yaml:"Type"` // string, int, number, bool, date, list or map. Defaults to string
+	Required bool     `yaml:"Required"`
+	Allowed  []string `yaml:"Allowed"` // Permitted values; every item for lists
+	Default  any      `yaml:"Default"` // Used when the field is missing
+}
+
+// builtinFrontMatter describes the fields easyblog itself reads.
+var builtinFrontMatter = map[string]FieldSchema{
+	"Title":        {Type: "string"},
+	"Date":         {Type: "date", Required: true},
+	"Updated":      {Type: "date"},
+	"Author":       {Type: "string"},
+	"Summary":      {Type: "string"},
+	"Tags":         {Type: "list"},
+	"Series":       {Type: "string"},
+	"SeriesOrder":  {Type: "int"},
+	"Syndications": {Type: "map"},
+}
+
+// frontMatterSchema returns the built-in schema with Config.FrontMatter
+// merged over it. With GitDates, Date may be left out.
+func (c Config) frontMatterSchema() map[string]FieldSchema {
+	schema := maps.Clone(builtinFrontMatter)
+	if c.GitDates {
+		date := schema["Date"]
+		date.Required = false
+		schema["Date"] = date
+	}
+	for name, field := range c.FrontMatter {
+		if field.Type == "" {
+			field.Type = schema[name].Type
+		}
+		schema[name] = field
+	}
+	return schema
+}
+
+// validateFrontMatter checks metaData against the schema and fills in
+// defaults. It returns the completed metadata (metaData itself is left
+// untouched) and one error per invalid field, each naming file and field.
+func (c Config) validateFrontMatter(file string, metaData map[string]any) (map[string]any, []error) {
+	schema := c.frontMatterSchema()
+	result := maps.Clone(metaData)
+	if result == nil {
+		result = map[string]any{}
+	}
+
+	names := slices.Sorted(maps.Keys(schema))
+	errs := []error{}
+	for _, name := range names {
+		field := schema[name]
+		value, ok := result[name]
+		if !ok || value == nil {
+			switch {
+			case field.Default != nil:
+				result[name] = field.Default
+			case field.Required:
+				errs = append(errs, fmt.Errorf("%s: front matter %s: required field is missing", file, name))
+			}
+			continue
+		}
+
+		if err := c.checkFieldType(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: front matter %s: %v", file, name, err))
+		}
+	}
+	return result, errs
+}
+
+// checkFieldType reports whether value has the declared type and one of the
+// allowed values.
+func (c Config) checkFieldType(field FieldSchema, value any) error {
+	values := []string{}
+
+	switch field.Type {
+	case "", "string":
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %s", describeValue(value))
+		}
+		values = append(values, v)
+	case "int":
+		n, ok := intValue(value)
+		if !ok {
+			return fmt.Errorf("expected a whole number, got %s", describeValue(value))
+		}
+		values = append(values, strconv.Itoa(n))
+	case "number":
+		switch v := value.(type) {
+		case int, int64, uint64, float64:
+			values = append(values, fmt.Sprint(v))
+		default:
+			return fmt.Errorf("expected a number, got %s", describeValue(value))
+		}
+	case "bool":
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false, got %s", describeValue(value))
+		}
+		values = append(values, strconv.FormatBool(v))
+	case "date":
+		if _, err := c.ParseDate(value); err != nil {
+			return err
+		}
+	case "list":
+		switch v := value.(type) {
+		case string:
+			for _, item := range strings.Split(v, ",") {
+				values = append(values, strings.TrimSpace(item))
+			}
+		case []any:
+			for _, item := range v {
+				switch item.(type) {
+				case string, int, int64, uint64, float64, bool:
+					values = append(values, fmt.Sprint(item))
+				default:
+					return fmt.Errorf("expected a list of values, got an item of %s", describeValue(item))
+				}
+			}
+		default:
+			return fmt.Errorf("expected a list or comma separated string, got %s", describeValue(value))
+		}
+	case "map":
+		switch value.(type) {
+		case map[any]any, map[string]any:
+		default:
+			return fmt.Errorf("expected a mapping, got %s", describeValue(value))
+		}
+	default:
+		return fmt.Errorf("unknown schema type %q", field.Type)
+	}
+
+	if len(field.Allowed) == 0 {
+		return nil
+	}
+	for _, v := range values {
+		if !slices.Contains(field.Allowed, v) {
+			allowed := slices.Clone(field.Allowed)
+			sort.Strings(allowed)
+			return fmt.Errorf("%q is not one of %s", v, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// intValue reads a whole number, which front matter may hand over as an
+// integer, a float or a string.
+func intValue(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func describeValue(value any) string {
+	switch value.(type) {
+	case []any:
+		return "a list"
+	case map[any]any, map[string]any:
+		return "a mapping"
+	case bool:
+		return fmt.Sprintf("the boolean %v", value)
+	case int, int64, uint64, float64:
+		return fmt.Sprintf("the number %v", value)
+	case string:
+		return fmt.Sprintf("the string %q", value)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// stringField returns a string front matter field, or "" when it is missing
+// or not a string.
+func stringField(metaData map[string]any, name string) string {
+	v, _ := metaData[name].(string)
+	return v
+}
+
+// frontMatterAliases maps keys used by other generators to easyblog's.
+var frontMatterAliases = map[string]string{
+	"lastmod": "Updated",
+}
+
+// canonicalizeKeys renames keys that only differ in case from a built-in
+// field (e.g. Hugo's lowercase title and date), or that are known aliases,
+// to the built-in name. Keys already spelled the built-in way win.
+func canonicalizeKeys(metaData map[string]any) {
+	for key, value := range metaData {
+		canonical, ok := frontMatterAliases[strings.ToLower(key)]
+		if !ok {
+			for name := range builtinFrontMatter {
+				if strings.EqualFold(name, key) {
+					canonical, ok = name, true
+				}
+			}
+		}
+		if !ok || canonical == key {
+			continue
+		}
+		if _, taken := metaData[canonical]; !taken {
+			metaData[canonical] = value
+			delete(metaData, key)
+		}
+	}
+}
+
+// splitFrontMatter separates TOML (+++ fenced, as written by Hugo) and JSON
+// (a leading object) front matter from the markdown body. YAML (--- fenced)
+// is left in place for goldmark-meta, in which case metaData is nil.
+func splitFrontMatter(source []byte) (metaData map[string]any, body []byte, err error) {
+	trimmed := bytes.TrimPrefix(source, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("+++\n")) || bytes.HasPrefix(trimmed, []byte("+++\r\n")):
+		header, rest, found := cutFence(trimmed, "+++")
+		if !found {
+			return nil, nil, fmt.Errorf("TOML front matter: missing closing +++")
+		}
+		metaData = map[string]any{}
+		if _, err := toml.Decode(string(header), &metaData); err != nil {
+			return nil, nil, fmt.Errorf("TOML front matter: %w", err)
+		}
+		return normalizeTOML(metaData).(map[string]any), rest, nil
+
+	case isJSONFrontMatter(trimmed):
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		metaData = map[string]any{}
+		if err := decoder.Decode(&metaData); err != nil {
+			return nil, nil, fmt.Errorf("JSON front matter: %w", err)
+		}
+		return metaData, trimmed[decoder.InputOffset():], nil
+	}
+	return nil, source, nil
+}
+
+// yamlFrontMatterLength returns the length of the YAML front matter source
+// starts with, fences included, or 0 if it has none.
+func yamlFrontMatterLength(source []byte) int {
+	if !bytes.HasPrefix(source, []byte("---\n")) && !bytes.HasPrefix(source, []byte("---\r\n")) {
+		return 0
+	}
+	_, rest, found := cutFence(source, "---")
+	if !found {
+		return 0
+	}
+	return len(source) - len(rest)
+}
+
+// cutFence splits a document starting with a fence line into the lines up
+// to the closing fence and everything after it.
+func cutFence(source []byte, fence string) (header []byte, rest []byte, found bool) {
+	_, after, _ := bytes.Cut(source, []byte("\n"))
+	offset := 0
+	for offset < len(after) {
+		line := after[offset:]
+		end := bytes.IndexByte(line, '\n')
+		if end < 0 {
+			end = len(line)
+		} else {
+			end++
+		}
+		if string(bytes.TrimRight(line[:end], "\r\n")) == fence {
+			return after[:offset], after[offset+end:], true
+		}
+		offset += end
+	}
+	return nil, nil, false
+}
+
+// isJSONFrontMatter reports whether source opens with a JSON object, i.e.
+// `{` followed by a key or `}`. Shortcodes ({{< ... >}}) don't qualify.
+func isJSONFrontMatter(source []byte) bool {
+	if !bytes.HasPrefix(source, []byte("{")) {
+		return false
+	}
+	rest := bytes.TrimLeft(source[1:], " \t\r\n")
+	return bytes.HasPrefix(rest, []byte(`"`)) || bytes.HasPrefix(rest, []byte("}"))
+}
+
+// normalizeTOML turns TOML local dates and date-times, which carry no
+// offset, into strings so that Config.ParseDate reads them in the site
+// timezone like their YAML counterparts.
+func normalizeTOML(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeTOML(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeTOML(item)
+		}
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalizeTOML(item)
+		}
+		return items
+	case int64:
+		return int(v)
+	case time.Time:
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format(time.DateOnly)
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05")
+		}
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GitDates holds the first and last commit dates of a file in the local git
+// history.
+type GitDates struct {
+	FirstCommit time.Time
+	LastCommit  time.Time
+}
+
+// loadGitDates reads the history of every file under dir with a single
+// `git log` call and returns their commit dates keyed by absolute path. Only
+// the local repository is read; nothing is fetched, so shallow clones, whose
+// history would date every file to their newest commit, are an error.
+func loadGitDates(dir string) (map[string]GitDates, error) {
+	topLevel, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %w", dir, err)
+	}
+	shallow, err := exec.Command("git", "-C", dir, "rev-parse", "--is-shallow-repository").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse: %w", err)
+	}
+	if strings.TrimSpace(string(shallow)) == "true" {
+		return nil, fmt.Errorf("%s is a shallow clone; fetch the full history (fetch-depth: 0 with actions/checkout)", dir)
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(string(topLevel)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Commits are listed newest first; each starts with a NUL-prefixed
+	// committer date followed by the files it touched.
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%x00%cI", "--name-only", "--", ".").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	dates := map[string]GitDates{}
+	var commitDate time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			commitDate, err = time.Parse(time.RFC3339, strings.TrimPrefix(line, "\x00"))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(line))
+		fileDates, seen := dates[path]
+		if !seen {
+			fileDates.LastCommit = commitDate
+		}
+		fileDates.FirstCommit = commitDate
+		dates[path] = fileDates
+	}
+	return dates, scanner.Err()
+}
+
+// fileDates stands in for the commit dates of a file when the git history
+// can't be read. Its modification time replaces the first commit; there is no
+// last commit, since a fresh checkout would mark every post as just updated.
+func fileDates(file string) *GitDates {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil
+	}
+	return &GitDates{FirstCommit: info.ModTime()}
+}
+
+// gitDatesFor looks up the commit dates of a file returned by loadGitDates.
+func gitDatesFor(dates map[string]GitDates, file string) *GitDates {
+	if dates == nil {
+		return nil
+	}
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if fileDates, ok := dates[path]; ok {
+		return &fileDates
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package builder
+
+import (
+	"maps"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/anchor"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	figure "github.com/mangoumbrella/goldmark-figure"
+	fences "github.com/stefanfritsch/goldmark-fences"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+)
+
+// MarkdownConfig turns the built-in markdown extensions on or off. Every
+// extension is on unless set to false. Front matter and [[wiki links]] are
+// always on.
+type MarkdownConfig struct {
+	GFM          *bool `yaml:"GFM"`          // Tables, strikethrough, autolinks and task lists
+	Figure       *bool `yaml:"Figure"`       // Images with a title become <figure>s
+	Fences       *bool `yaml:"Fences"`       // ::: fenced <div>s
+	Anchors      *bool `yaml:"Anchors"`      // # permalinks next to headings
+	Highlighting *bool `yaml:"Highlighting"` // Chroma syntax highlighting, styled with CodeStyle
+	Callouts     *bool `yaml:"Callouts"`     // > [!NOTE] and :::tip blocks
+	Footnotes    *bool `yaml:"Footnotes"`    // [^1] references and notes
+	Sidenotes    bool  `yaml:"Sidenotes"`    // Also render each footnote next to its reference
+	Math         *bool `yaml:"Math"`         // $inline$ and $$display$$ formulas
+
+	// MathOutput is mathml (the default) to convert formulas to MathML at
+	// build time, or tex to write out the TeX for a client-side renderer such
+	// as KaTeX or MathJax.
+	MathOutput string `yaml:"MathOutput"`
+
+	Diagrams *bool `yaml:"Diagrams"` // ```goat blocks (and MarkdownExtensions.Diagrams) as inline SVG
+
+	// DiagramCache is a directory rendered diagrams are kept in between
+	// builds, e.g. .cache/diagrams. Without it they are only cached in memory.
+	DiagramCache string `yaml:"DiagramCache"`
+
+	// CalloutTypes adds callout types or relabels the built-in ones (note,
+	// tip, important, warning, caution), e.g. info: Did you know?. An empty
+	// label turns a type off.
+	CalloutTypes map[string]string `yaml:"CalloutTypes"`
+}
+
+func enabled(option *bool) bool {
+	return option == nil || *option
+}
+
+// MarkdownExtensions are added to the built-in markdown pipeline, e.g. from
+// entrypoint.EasyblogOpts.
+type MarkdownExtensions struct {
+	Extenders       []goldmark.Extender
+	ParserOptions   []parser.Option
+	RendererOptions []renderer.Option
+	ASTTransformers []util.PrioritizedValue // e.g. util.Prioritized(myTransformer, 500)
+
+	// Diagrams renders fenced code blocks in more languages to SVG, e.g.
+	// pikchr, d2 or graphviz. They take precedence over the built-in goat.
+	Diagrams map[string]DiagramFunc
+}
+
+type customTexter struct{}
+
+func (*customTexter) AnchorText(h *anchor.HeaderInfo) []byte {
+	if h.Level == 1 {
+		return nil
+	}
+	return []byte("#")
+}
+
+// NewMarkdown returns a goldmark instance configured with the enabled
+// built-in extensions plus extra. It is safe for concurrent use, so a build
+// creates one and shares it across every document.
+func NewMarkdown(config Config, extra MarkdownExtensions) goldmark.Markdown {
+	extenders := []goldmark.Extender{}
+	if enabled(config.Markdown.GFM) {
+		extenders = append(extenders, extension.GFM)
+	}
+	extenders = append(extenders, meta.Meta)
+	if enabled(config.Markdown.Figure) {
+		extenders = append(extenders, figure.Figure)
+	}
+	if enabled(config.Markdown.Anchors) {
+		extenders = append(extenders, &anchor.Extender{
+			Attributer: anchor.Attributes{
+				"class": "headerPermalink",
+			},
+			Texter: &customTexter{},
+		})
+	}
+	if enabled(config.Markdown.Fences) {
+		extenders = append(extenders, &fences.Extender{})
+	}
+	if enabled(config.Markdown.Callouts) {
+		extenders = append(extenders, &calloutExtender{types: config.calloutTypes()})
+	}
+	if enabled(config.Markdown.Footnotes) {
+		extenders = append(extenders, extension.NewFootnote(
+			extension.WithFootnoteIDPrefixFunction(footnoteIDPrefix),
+		))
+		if config.Markdown.Sidenotes {
+			extenders = append(extenders, &sidenoteExtender{})
+		}
+	}
+	if enabled(config.Markdown.Math) {
+		extenders = append(extenders, &mathExtender{mathML: config.Markdown.MathOutput != "tex"})
+	}
+	if enabled(config.Markdown.Diagrams) {
+		diagrams := maps.Clone(builtinDiagrams)
+		maps.Copy(diagrams, extra.Diagrams)
+		extenders = append(extenders, &diagramExtender{diagrams: diagrams, cacheDir: config.Markdown.DiagramCache})
+	}
+	extenders = append(extenders, &wikiLinkExtender{})
+	if enabled(config.Markdown.Highlighting) {
+		extenders = append(extenders, highlighting.NewHighlighting(
+			highlighting.WithStyle(config.CodeStyle),
+			highlighting.WithFormatOptions(
+				chromahtml.WithLineNumbers(true),
+			),
+		))
+	}
+	extenders = append(extenders, extra.Extenders...)
+
+	parserOptions := []parser.Option{
+		parser.WithAutoHeadingID(), // read note
+	}
+	if len(extra.ASTTransformers) > 0 {
+		parserOptions = append(parserOptions, parser.WithASTTransformers(extra.ASTTransformers...))
+	}
+	parserOptions = append(parserOptions, extra.ParserOptions...)
+
+	return goldmark.New(
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(extra.RendererOptions...),
+		goldmark.WithExtensions(extenders...),
+	)
+}
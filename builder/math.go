@@ -0,0 +1,339 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wyatt915/treeblood"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Math is written as $inline$ or $$display$$, the latter also on lines of
+// its own:
+//
+//	$$
+//	x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}
+//	$$
+//
+// Following pandoc, an opening $ must not be followed by a space and a
+// closing $ must not be preceded by one or followed by a digit, so prices
+// like $5 and $10 stay text. \$ is a literal dollar sign.
+//
+// Formulas are converted to MathML while parsing, one treeblood document per
+// post so \newcommand definitions carry over between formulas. With
+// MathOutput: tex, or when a formula can't be converted, the TeX is written
+// out instead for a client-side renderer:
+//
+//	<span class="math math-inline">\(x^2\)</span>
+//	<div class="math math-display">\[x^2\]</div>
+//
+// Formulas that can't be converted also get a math-error class, and are
+// reported through renderMarkdown as warnings with their line.
+
+// formula is the TeX of a Math or MathBlock and what it was converted to.
+type formula struct {
+	TeX     string
+	Display bool
+
+	offset int    // Position of the formula in the source, for warnings
+	mathML string // Set by mathTransformer unless the TeX is written out
+	failed bool
+}
+
+var KindMath = ast.NewNodeKind("Math")
+
+// Math is a formula within a paragraph: $inline$ or $$display$$.
+type Math struct {
+	ast.BaseInline
+	formula
+}
+
+func (n *Math) Kind() ast.NodeKind { return KindMath }
+
+func (n *Math) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX, "Display": fmt.Sprint(n.Display)}, nil)
+}
+
+var KindMathBlock = ast.NewNodeKind("MathBlock")
+
+// MathBlock is a $$ formula on lines of its own.
+type MathBlock struct {
+	ast.BaseBlock
+	formula
+	closed bool
+}
+
+func (n *MathBlock) Kind() ast.NodeKind { return KindMathBlock }
+
+// IsRaw keeps goldmark from parsing the TeX as inline markdown.
+func (n *MathBlock) IsRaw() bool { return true }
+
+func (n *MathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX}, nil)
+}
+
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	delimiter := 1
+	if len(line) > 1 && line[1] == '$' {
+		delimiter = 2
+	}
+	body := line[delimiter:]
+	if len(body) == 0 || (delimiter == 1 && isSpace(body[0])) {
+		return nil
+	}
+
+	for i := 0; i < len(body); i++ {
+		switch {
+		case body[i] == '\\':
+			i++
+		case body[i] == '`':
+			// Formulas don't run into code spans.
+			return nil
+		case body[i] != '$':
+		case delimiter == 2:
+			if i+1 < len(body) && body[i+1] == '$' && i > 0 {
+				block.Advance(delimiter + i + 2)
+				return &Math{formula: formula{TeX: strings.TrimSpace(string(body[:i])), Display: true, offset: segment.Start}}
+			}
+		default:
+			if i == 0 || isSpace(body[i-1]) || (i+1 < len(body) && body[i+1] >= '0' && body[i+1] <= '9') {
+				continue
+			}
+			block.Advance(delimiter + i + 1)
+			return &Math{formula: formula{TeX: string(body[:i]), offset: segment.Start}}
+		}
+	}
+	return nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	node := &MathBlock{formula: formula{Display: true, offset: segment.Start + pos}}
+	rest := segment.WithStart(segment.Start + pos + 2)
+	content := bytes.TrimRight(rest.Value(reader.Source()), " \t\r\n")
+	closing := bytes.Index(content, []byte("$$"))
+	switch {
+	case closing < 0:
+	case closing == len(content)-2:
+		// $$ x $$ on one line.
+		rest.Stop = rest.Start + closing
+		node.closed = true
+	default:
+		// $$ x $$ followed by text is inline math in a paragraph.
+		return nil, parser.NoChildren
+	}
+	if !util.IsBlank(rest.Value(reader.Source())) {
+		node.Lines().Append(rest)
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*MathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	content := bytes.TrimRight(line, " \t\r\n")
+	if bytes.HasSuffix(content, []byte("$$")) {
+		last := segment.WithStop(segment.Start + len(content) - 2)
+		if !util.IsBlank(last.Value(reader.Source())) {
+			n.Lines().Append(last)
+		}
+		n.closed = true
+		reader.Advance(segment.Len() - 1)
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	n := node.(*MathBlock)
+	var tex bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		tex.Write(line.Value(reader.Source()))
+	}
+	n.TeX = strings.TrimSpace(tex.String())
+	if !n.closed {
+		n.failed = true
+		addParseWarning(pc, n.offset, fmt.Errorf("math: missing closing $$"))
+	}
+}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type mathTransformer struct {
+	mathML bool
+}
+
+var mathErrorPattern = regexp.MustCompile(`<merror(?:\s+title="([^"]*)")?[^>]*>([^<]*)</merror>`)
+
+func (t *mathTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	formulas := []*formula{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *Math:
+			formulas = append(formulas, &n.formula)
+		case *MathBlock:
+			formulas = append(formulas, &n.formula)
+		}
+		return ast.WalkContinue, nil
+	})
+	if len(formulas) == 0 {
+		return
+	}
+
+	document := treeblood.NewDocument(nil, false)
+	document.PrintOneLine = true
+	for _, math := range formulas {
+		if math.failed {
+			continue
+		}
+		var mathML string
+		var err error
+		if math.Display {
+			mathML, err = document.DisplayStyle(math.TeX)
+		} else {
+			mathML, err = document.TextStyle(math.TeX)
+		}
+		if err == nil {
+			if m := mathErrorPattern.FindStringSubmatch(mathML); m != nil {
+				err = fmt.Errorf("unknown command \\%s", html.UnescapeString(m[2]))
+				if m[1] != "" {
+					err = fmt.Errorf("\\%s:%s", html.UnescapeString(m[2]), html.UnescapeString(m[1]))
+				}
+			}
+		}
+		if err != nil {
+			// treeblood points at the problem in an HTML <pre> block.
+			message, _, _ := strings.Cut(err.Error(), "<pre>")
+			addParseWarning(pc, math.offset, fmt.Errorf("math: %s in %q", strings.TrimSpace(message), math.TeX))
+			math.failed = true
+			continue
+		}
+		if t.mathML {
+			math.mathML = sortAttributes(strings.TrimSpace(mathML))
+		}
+	}
+}
+
+var (
+	tagAttributesPattern = regexp.MustCompile(`<([a-z]+)((?:\s+[\w:-]+="[^"]*")+)\s*(/?)>`)
+	attributePattern     = regexp.MustCompile(`[\w:-]+="[^"]*"`)
+)
+
+// sortAttributes puts the attributes of every tag in order; treeblood writes
+// them in map order, which would make every build differ.
+func sortAttributes(markup string) string {
+	return tagAttributesPattern.ReplaceAllStringFunc(markup, func(tag string) string {
+		m := tagAttributesPattern.FindStringSubmatch(tag)
+		attributes := attributePattern.FindAllString(m[2], -1)
+		sort.Strings(attributes)
+		return "<" + m[1] + " " + strings.Join(attributes, " ") + m[3] + ">"
+	})
+}
+
+type mathRenderer struct{}
+
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMath, r.renderMath)
+	reg.Register(KindMathBlock, r.renderMathBlock)
+}
+
+func (r *mathRenderer) renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		writeFormula(w, node.(*Math).formula, "span")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		writeFormula(w, node.(*MathBlock).formula, "div")
+		_ = w.WriteByte('\n')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeFormula writes the MathML of f, or its TeX in a tag element.
+func writeFormula(w util.BufWriter, f formula, tag string) {
+	if f.mathML != "" {
+		_, _ = w.WriteString(f.mathML)
+		return
+	}
+	class, open, close := "math math-inline", `\(`, `\)`
+	if f.Display {
+		class, open, close = "math math-display", `\[`, `\]`
+	}
+	if f.failed {
+		class += " math-error"
+	}
+	_, _ = w.WriteString("<" + tag + ` class="` + class + `">` + open + html.EscapeString(f.TeX) + close + "</" + tag + ">")
+}
+
+type mathExtender struct {
+	mathML bool
+}
+
+func (e *mathExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(&mathBlockParser{}, 650),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(&mathInlineParser{}, 150), // before emphasis, so $a*b*c$ stays math
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(&mathTransformer{mathML: e.mathML}, 100),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&mathRenderer{}, 500),
+	))
+}
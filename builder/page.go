@@ -0,0 +1,142 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yuin/goldmark"
+)
+
+// Page is a standalone markdown page (about, uses, now) living in
+// <input>/pages/ and rendered through templates/page.html.
+type Page struct {
+	Title       string
+	OGName      string
+	Body        template.HTML
+	Summary     string
+	Slug        string
+	OGImageURL  string
+	ToC         template.HTML
+	RawMetadata map[string]any
+
+	shortcodes []shortcodeCall
+}
+
+func ParsePage(md goldmark.Markdown, config Config, fileName string) (Page, error) {
+	pageMd, err := os.ReadFile(filepath.Join(config.InputDirectory, "pages", fileName))
+	if err != nil {
+		return Page{}, err
+	}
+
+	rendered, err := renderMarkdown(md, pageMd, strings.TrimSuffix(fileName, ".md")+"-")
+	if err != nil {
+		return Page{}, fmt.Errorf("%s: %w", filepath.Join("pages", fileName), err)
+	}
+	logWarnings(filepath.Join("pages", fileName), rendered.Warnings)
+	metaData := rendered.Metadata
+
+	strippedFileName := strings.TrimSuffix(fileName, ".md")
+
+	title := strings.ReplaceAll(strippedFileName, "-", " ")
+	if v, ok := metaData["Title"].(string); ok {
+		title = v
+	}
+
+	summary := ""
+	if v, ok := metaData["Summary"].(string); ok {
+		summary = v
+	}
+
+	return Page{
+		Title:       title,
+		OGName:      fmt.Sprintf("page-%s", strippedFileName),
+		Body:        rendered.Body,
+		Summary:     summary,
+		Slug:        fmt.Sprintf("/%s", strippedFileName),
+		OGImageURL:  fmt.Sprintf("%s/og_images/page-%s.png", config.BaseURL, strippedFileName),
+		ToC:         rendered.ToC,
+		RawMetadata: metaData,
+		shortcodes:  rendered.Shortcodes,
+	}, nil
+}
+
+// checkPageName reports a page published at a path the builder writes
+// itself, such as the homepage (pages/index.md) or a taxonomy; one would
+// overwrite the other.
+func (c Config) checkPageName(fileName string) error {
+	slug := "/" + strings.TrimSuffix(fileName, ".md")
+	reserved := append([]string{"/index", "/sitemap"}, reservedPaths...)
+	for _, taxonomy := range c.Taxonomies {
+		reserved = append(reserved, taxonomy.basePath())
+	}
+	for _, path := range reserved {
+		if strings.EqualFold(slug, path) {
+			return fmt.Errorf("%s: would be published at %s, which is reserved; rename it", filepath.Join("pages", fileName), slug)
+		}
+	}
+	return nil
+}
+
+// buildPages renders every markdown file in <input>/pages/ to out/<name>.html.
+// Sites without a pages directory are left untouched.
+func (b *Builder) buildPages() {
+	defer b.staticFilesCreated.Done()
+
+	files, err := os.ReadDir(filepath.Join(b.Config.InputDirectory, "pages"))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	b.setupWaitGroup.Wait()
+	b.postsSorted.Wait() // pages may [[wiki link]] to posts
+
+	if b.pageTemplate == nil {
+		panic("pages/ exists but templates/page.html is missing")
+	}
+
+	var wg sync.WaitGroup
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+			continue
+		}
+		if err := b.Config.checkPageName(file.Name()); err != nil {
+			b.addBuildError(err)
+			continue
+		}
+		wg.Add(1)
+		go func(fileName string) {
+			defer wg.Done()
+			page, err := ParsePage(b.markdown, b.Config, fileName)
+			if err != nil {
+				b.addBuildError(err)
+				return
+			}
+			sourceFile := filepath.Join("pages", fileName)
+			page.Body = b.expandShortcodes(sourceFile, page.Body, page.shortcodes, nil, &page)
+			page.Body = b.resolveWikiLinks(sourceFile, page.Body)
+
+			var doc bytes.Buffer
+			if err := b.pageTemplate.Execute(&doc, page); err != nil {
+				panic(err)
+			}
+
+			b.sitemap.AddPageURL(page.Slug)
+
+			err = os.WriteFile(outputPathForSlug(page.Slug), doc.Bytes(), 0644)
+			if err != nil {
+				panic(err)
+			}
+
+			b.generateOG(page.Title, fmt.Sprintf("./out/og_images/%s.png", page.OGName))
+		}(file.Name())
+	}
+	wg.Wait()
+}
@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Paginator is handed to index.html and tag.html when PageSize is set.
+type Paginator struct {
+	PageNumber int
+	TotalPages int
+	Items      PostList
+	URL        string
+	PrevURL    string
+	NextURL    string
+}
+
+func (p *Paginator) HasPrev() bool { return p.PrevURL != "" }
+func (p *Paginator) HasNext() bool { return p.NextURL != "" }
+
+// paginate splits posts into pages of pageSize. The first page lives at
+// baseURL, the rest at <baseURL>/page/<n>/.
+func paginate(posts PostList, pageSize int, baseURL string) []*Paginator {
+	totalPages := (len(posts) + pageSize - 1) / pageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pages := make([]*Paginator, totalPages)
+	for i := range pages {
+		start := i * pageSize
+		end := min(start+pageSize, len(posts))
+
+		page := &Paginator{
+			PageNumber: i + 1,
+			TotalPages: totalPages,
+			Items:      posts[start:end],
+			URL:        pageURL(baseURL, i+1),
+		}
+		if i > 0 {
+			page.PrevURL = pageURL(baseURL, i)
+		}
+		if i < totalPages-1 {
+			page.NextURL = pageURL(baseURL, i+2)
+		}
+		pages[i] = page
+	}
+	return pages
+}
+
+func pageURL(baseURL string, pageNumber int) string {
+	if pageNumber == 1 {
+		return baseURL
+	}
+	return fmt.Sprintf("%s/page/%d/", strings.TrimSuffix(baseURL, "/"), pageNumber)
+}
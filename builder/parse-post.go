@@ -2,134 +2,256 @@ package builder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
+	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
-	figure "github.com/mangoumbrella/goldmark-figure"
-	fences "github.com/stefanfritsch/goldmark-fences"
 	"github.com/yuin/goldmark"
 	meta "github.com/yuin/goldmark-meta"
-	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
-	"go.abhg.dev/goldmark/anchor"
 	"go.abhg.dev/goldmark/toc"
-
-	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
 )
 
-type customTexter struct{}
+type renderedMarkdown struct {
+	Body       template.HTML
+	ToC        template.HTML
+	Metadata   map[string]any
+	WikiLinks  []string        // Targets of [[wiki links]], resolved after every post is parsed
+	Shortcodes []shortcodeCall // Expanded into Body by Builder.expandShortcodes
+	Warnings   []error         // Problems that don't fail the build, e.g. invalid math
+}
 
-func (*customTexter) AnchorText(h *anchor.HeaderInfo) []byte {
-	if h.Level == 1 {
-		return nil
+// renderMarkdown converts a markdown document into its HTML body, table of
+// contents and front matter, which may be YAML, TOML or JSON. The document is
+// parsed once; the same AST feeds the table of contents and the HTML.
+// Footnote ids start with idPrefix, which should be unique per document.
+func renderMarkdown(md goldmark.Markdown, source []byte, idPrefix string) (renderedMarkdown, error) {
+	frontMatter, body, err := splitFrontMatter(source)
+	if err != nil {
+		return renderedMarkdown{}, err
 	}
-	return []byte("#")
-}
+	lineOffset := bytes.Count(source[:len(source)-len(body)], []byte("\n"))
 
-func ParsePost(postsChan chan<- Post, metadataChan chan<- PostMetadata, config Config, fileName string) {
-	postMd, err := os.ReadFile(fmt.Sprintf("%s/posts/%s", config.InputDirectory, fileName))
+	// YAML front matter is left for goldmark-meta, but like the others it
+	// mustn't have shortcodes replaced.
+	header := body[:yamlFrontMatterLength(body)]
+	content, shortcodes, err := extractShortcodes(body[len(header):], lineOffset+bytes.Count(header, []byte("\n"))+1, idPrefix)
 	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
-		return
-	}
-
-	md := goldmark.New(
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(), // read note
-		),
-		goldmark.WithExtensions(extension.GFM, meta.Meta, figure.Figure, &anchor.Extender{
-			Attributer: anchor.Attributes{
-				"class": "headerPermalink",
-			},
-			Texter: &customTexter{},
-		},
-			&fences.Extender{},
-			highlighting.NewHighlighting(
-				highlighting.WithStyle(config.CodeStyle),
-				highlighting.WithFormatOptions(
-					chromahtml.WithLineNumbers(true),
-				),
-			)),
-	)
+		return renderedMarkdown{}, err
+	}
+	source = append(header[:len(header):len(header)], content...)
+	for i := range shortcodes {
+		shortcodes[i].offset += len(header)
+	}
 
-	var buf bytes.Buffer
+	context := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(context))
+	doc.SetAttributeString(footnotePrefixAttribute, []byte(idPrefix))
 
-	src := text.NewReader(postMd)
-	doc := md.Parser().Parse(src)
-	tree, err := toc.Inspect(doc, postMd, toc.MinDepth(2), toc.MaxDepth(3))
+	tree, err := toc.Inspect(doc, source, toc.MinDepth(2), toc.MaxDepth(3))
 	if err != nil {
-		panic(err)
-		// handle the error
+		return renderedMarkdown{}, err
 	}
 
-	list := toc.RenderList(tree)
-
-	toc := template.HTML("")
-	if list != nil {
+	tocHTML := template.HTML("")
+	if list := toc.RenderList(tree); list != nil {
 		var tocBuff bytes.Buffer
-		err = md.Renderer().Render(&tocBuff, []byte{}, list)
+		if err := md.Renderer().Render(&tocBuff, []byte{}, list); err != nil {
+			return renderedMarkdown{}, err
+		}
+		tocHTML = template.HTML(tocBuff.String())
+	}
 
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return renderedMarkdown{}, err
+	}
+
+	if frontMatter == nil {
+		frontMatter, err = meta.TryGet(context)
 		if err != nil {
-			panic(err)
+			return renderedMarkdown{}, fmt.Errorf("YAML front matter: %w", err)
 		}
-		toc = template.HTML(tocBuff.String())
 	}
-	context := parser.NewContext()
-	if err := md.Convert(postMd, &buf, parser.WithContext(context)); err != nil {
-		panic(err)
+	canonicalizeKeys(frontMatter)
+
+	return renderedMarkdown{
+		Body:       template.HTML(buf.String()),
+		ToC:        tocHTML,
+		Metadata:   frontMatter,
+		WikiLinks:  collectWikiLinks(doc),
+		Shortcodes: shortcodes,
+		Warnings:   parseWarnings(context, source, lineOffset, shortcodes),
+	}, nil
+}
+
+// parseWarning is a problem found while parsing that doesn't fail the build,
+// such as a formula that can't be converted.
+type parseWarning struct {
+	offset int // Position in the source
+	err    error
+}
+
+var parseWarningsKey = parser.NewContextKey()
+
+func addParseWarning(pc parser.Context, offset int, err error) {
+	warnings, _ := pc.Get(parseWarningsKey).([]parseWarning)
+	pc.Set(parseWarningsKey, append(warnings, parseWarning{offset, err}))
+}
+
+// parseWarnings returns the warnings recorded while parsing source, in order,
+// as errors naming their line. lineOffset is added to each line, for front
+// matter cut off before parsing, as are the lines of the shortcodes before
+// the warning, which source only holds placeholders for.
+func parseWarnings(pc parser.Context, source []byte, lineOffset int, shortcodes []shortcodeCall) []error {
+	warnings, _ := pc.Get(parseWarningsKey).([]parseWarning)
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].offset < warnings[j].offset })
+	errs := []error{}
+	for _, warning := range warnings {
+		line := lineOffset + bytes.Count(source[:warning.offset], []byte("\n")) + 1
+		for _, call := range shortcodes {
+			if call.offset < warning.offset {
+				line += call.lines
+			}
+		}
+		errs = append(errs, fmt.Errorf("line %d: %v", line, warning.err))
 	}
+	return errs
+}
 
-	metaData := meta.Get(context)
+// logWarnings prints the warnings of a rendered file without failing the
+// build.
+func logWarnings(file string, warnings []error) {
+	for _, warning := range warnings {
+		log.Printf("warning: %s: %v", file, warning)
+	}
+}
 
-	strippedFileName := fileName[:len(fileName)-3]
+// ParsePost renders a post with md and sends it to postsChan and metadataChan. Front
+// matter that doesn't match the schema is returned as an error per field; the
+// post is still sent so every problem in the site is reported at once.
+func ParsePost(postsChan chan<- Post, metadataChan chan<- PostMetadata, md goldmark.Markdown, config Config, fileName string, section *Section, gitDates *GitDates) error {
+	sourceFile := filepath.Join("posts", fileName)
+	postMd, err := os.ReadFile(filepath.Join(config.InputDirectory, sourceFile))
+	if err != nil {
+		return err
+	}
+
+	// postPath is relative to posts/ without the extension, e.g. guides/my-post.
+	postPath := strings.TrimSuffix(filepath.ToSlash(fileName), ".md")
+	slug := fmt.Sprintf("/post/%s", postPath)
 
-	tags := []string{}
-	if v, ok := metaData["Tags"].(string); ok {
-		tags = strings.Split(v, ", ")
+	// Page bundles (posts/my-post/index.md) are rendered to
+	// out/post/my-post/index.html so that relative links to sibling files
+	// resolve next to the post.
+	bundleDir := ""
+	if path.Base(postPath) == "index" {
+		postPath = path.Dir(postPath)
+		slug = fmt.Sprintf("/post/%s/", postPath)
+		bundleDir = filepath.Join(config.InputDirectory, "posts", filepath.FromSlash(postPath))
 	}
 
+	strippedFileName := path.Base(postPath)
+	ogName := strings.ReplaceAll(postPath, "/", "-")
+
+	rendered, err := renderMarkdown(md, postMd, ogName+"-")
+	if err != nil {
+		return fmt.Errorf("%s: %w", sourceFile, err)
+	}
+	logWarnings(sourceFile, rendered.Warnings)
+	metaData, errs := config.validateFrontMatter(sourceFile, rendered.Metadata)
+
+	tags := parseTerms(metaData["Tags"], config.TagAliases)
+
 	title := strings.ReplaceAll(strippedFileName, "-", " ")
 
 	if v, ok := metaData["Title"].(string); ok {
 		title = v
 	}
 
+	date := dateString(metaData["Date"])
+	publishedAt, _ := config.ParseDate(metaData["Date"])
+	if metaData["Date"] == nil && config.GitDates {
+		if gitDates == nil {
+			errs = append(errs, fmt.Errorf("%s: front matter Date: missing, and the file has no git history", sourceFile))
+		} else {
+			// No Date in the front matter: fall back to the first commit.
+			publishedAt = gitDates.FirstCommit.In(config.Location())
+			date = publishedAt.Format(time.DateOnly)
+		}
+	}
+
+	var updatedAt time.Time
+	if v, ok := metaData["Updated"]; ok {
+		updatedAt, _ = config.ParseDate(v)
+	} else if gitDates != nil {
+		updatedAt = gitDates.LastCommit.In(config.Location())
+	}
+
+	author := stringField(metaData, "Author")
+	summary := stringField(metaData, "Summary")
+	seriesName := stringField(metaData, "Series")
+	seriesOrder, _ := intValue(metaData["SeriesOrder"])
+
 	syndications := map[string]string{}
-	if v, ok := metaData["Syndications"].(map[any]any); ok {
+	switch v := metaData["Syndications"].(type) {
+	case map[any]any:
+		for k, v := range v {
+			syndications[fmt.Sprint(k)] = fmt.Sprint(v)
+		}
+	case map[string]any:
 		for k, v := range v {
-			syndications[k.(string)] = v.(string)
+			syndications[k] = fmt.Sprint(v)
 		}
 	}
 
 	postsChan <- Post{
 		Title:        title,
-		Slug:         fmt.Sprintf("/post/%s", strippedFileName),
-		Body:         template.HTML(buf.String()),
-		OGName:       strippedFileName,
-		Date:         metaData["Date"].(string),
-		Author:       metaData["Author"].(string),
-		Summary:      metaData["Summary"].(string),
+		Slug:         slug,
+		Body:         rendered.Body,
+		OGName:       ogName,
+		Date:         date,
+		PublishedAt:  publishedAt,
+		UpdatedAt:    updatedAt,
+		Author:       author,
+		Summary:      summary,
 		Tags:         tags,
-		ToC:          toc,
-		OGImageURL:   fmt.Sprintf("%s/og_images/%s.png", config.BaseURL, strippedFileName),
+		Section:      section,
+		ToC:          rendered.ToC,
+		OGImageURL:   fmt.Sprintf("%s/og_images/%s.png", config.BaseURL, ogName),
 		RawMetadata:  metaData,
 		Syndications: syndications,
+		SeriesName:   seriesName,
+		SeriesOrder:  seriesOrder,
+		bundleDir:    bundleDir,
+		sourceFile:   sourceFile,
+		wikiLinks:    rendered.WikiLinks,
+		shortcodes:   rendered.Shortcodes,
 	}
 
 	metadataChan <- PostMetadata{
 		RawMetadata:  metaData,
-		Slug:         fmt.Sprintf("/post/%s", strippedFileName),
+		Slug:         slug,
 		Title:        title,
-		Date:         metaData["Date"].(string),
-		Summary:      metaData["Summary"].(string),
-		Author:       metaData["Author"].(string),
+		Date:         date,
+		PublishedAt:  publishedAt,
+		UpdatedAt:    updatedAt,
+		Summary:      summary,
+		Author:       author,
 		Syndications: syndications,
 		Tags:         tags,
+		Section:      section,
+		SeriesName:   seriesName,
+		SeriesOrder:  seriesOrder,
 	}
 
-	fmt.Println(syndications)
+	return errors.Join(errs...)
 }
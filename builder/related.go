@@ -0,0 +1,239 @@
+package builder
+
+import (
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxRelatedTerms caps the number of terms kept per post. Only the terms
+// with the highest TF-IDF weight matter for similarity, and the cap keeps
+// scoring roughly linear in the number of posts.
+const maxRelatedTerms = 50
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "this": true, "with": true,
+	"you": true, "your": true, "are": true, "was": true, "but": true, "not": true,
+	"have": true, "has": true, "can": true, "from": true, "will": true, "they": true,
+	"then": true, "than": true, "there": true, "their": true, "what": true, "when": true,
+	"which": true, "into": true, "just": true, "about": true, "all": true, "also": true,
+	"our": true, "out": true, "its": true, "it's": true, "some": true, "more": true,
+	"use": true, "how": true, "one": true, "would": true, "could": true, "should": true,
+}
+
+type weightedTerm struct {
+	term   string
+	weight float64
+}
+
+// BuildRelated scores every pair of posts by shared tags and the cosine
+// similarity of their TF-IDF weighted body text, and returns the top
+// config.Count related posts per slug. list is the sorted (newest first)
+// metadata of posts; ties go to the newer post.
+func BuildRelated(posts []Post, list PostList, config RelatedConfig) map[string]PostList {
+	related := map[string]PostList{}
+	if config.Count <= 0 || len(posts) < 2 {
+		return related
+	}
+
+	tagWeight, contentWeight := config.TagWeight, config.ContentWeight
+	if tagWeight == 0 && contentWeight == 0 {
+		tagWeight, contentWeight = 1, 1
+	}
+
+	metaBySlug := map[string]PostMetadata{}
+	listIndex := map[string]int{}
+	for i, meta := range list {
+		metaBySlug[meta.Slug] = meta
+		listIndex[meta.Slug] = i
+	}
+	// order[i] is the position of posts[i] in the sorted list.
+	order := make([]int, len(posts))
+	for i, post := range posts {
+		order[i] = listIndex[post.Slug]
+	}
+
+	// Term frequencies (tokenizing is the expensive part, so it runs in
+	// parallel) and document frequencies.
+	termCounts := make([]map[string]int, len(posts))
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				termCounts[i] = countTerms(string(posts[i].Body))
+			}
+		}()
+	}
+	for i := range posts {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	docFreq := map[string]int{}
+	for _, counts := range termCounts {
+		for term := range counts {
+			docFreq[term]++
+		}
+	}
+
+	// Inverted indexes from term and tag to the posts using them.
+	type posting struct {
+		doc    int
+		weight float64
+	}
+	termIndex := map[string][]posting{}
+	vectors := make([][]weightedTerm, len(posts))
+	tagIndex := map[string][]int{}
+	for i, post := range posts {
+		vectors[i] = tfidfVector(termCounts[i], docFreq, len(posts))
+		for _, wt := range vectors[i] {
+			termIndex[wt.term] = append(termIndex[wt.term], posting{i, wt.weight})
+		}
+		for _, tag := range post.Tags {
+			tagIndex[tagSlug(tag)] = append(tagIndex[tagSlug(tag)], i)
+		}
+	}
+
+	scores := make([]float64, len(posts))
+	touched := []int{}
+	for i, post := range posts {
+		touched = touched[:0]
+		add := func(doc int, score float64) {
+			if doc == i || score == 0 {
+				return
+			}
+			if scores[doc] == 0 {
+				touched = append(touched, doc)
+			}
+			scores[doc] += score
+		}
+
+		for _, tag := range post.Tags {
+			for _, doc := range tagIndex[tagSlug(tag)] {
+				add(doc, tagWeight)
+			}
+		}
+		for _, wt := range vectors[i] {
+			for _, p := range termIndex[wt.term] {
+				add(p.doc, contentWeight*wt.weight*p.weight)
+			}
+		}
+
+		// Keep the config.Count best scores; ties go to the newer post.
+		better := func(a, b int) bool {
+			if scores[a] != scores[b] {
+				return scores[a] > scores[b]
+			}
+			return order[a] < order[b]
+		}
+		best := make([]int, 0, config.Count+1)
+		for _, doc := range touched {
+			if len(best) == config.Count && !better(doc, best[len(best)-1]) {
+				continue
+			}
+			pos := sort.Search(len(best), func(k int) bool { return better(doc, best[k]) })
+			best = slices.Insert(best, pos, doc)
+			if len(best) > config.Count {
+				best = best[:config.Count]
+			}
+		}
+
+		matches := make(PostList, 0, len(best))
+		for _, doc := range best {
+			matches = append(matches, metaBySlug[posts[doc].Slug])
+		}
+		related[post.Slug] = matches
+
+		for _, doc := range touched {
+			scores[doc] = 0
+		}
+	}
+	return related
+}
+
+// countTerms counts the words of a rendered HTML body, ignoring markup,
+// stop words and words shorter than three letters.
+func countTerms(body string) map[string]int {
+	counts := map[string]int{}
+
+	// Markup and HTML entities are treated as word separators.
+	text := body
+	inTag := false
+	inEntity := false
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.ToLower(strings.Trim(text[start:end], "'"))
+		start = -1
+		if len(word) < 3 || stopWords[word] {
+			return
+		}
+		counts[word]++
+	}
+
+	for i, r := range text {
+		switch {
+		case r == '<':
+			flush(i)
+			inTag = true
+		case r == '>':
+			inTag = false
+		case inTag:
+		case r == '&':
+			flush(i)
+			inEntity = true
+		case inEntity:
+			inEntity = r != ';' && r != ' '
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			if start < 0 {
+				start = i
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return counts
+}
+
+// tfidfVector weights term counts by inverse document frequency, keeps the
+// strongest maxRelatedTerms and normalizes the result to unit length.
+func tfidfVector(counts map[string]int, docFreq map[string]int, docs int) []weightedTerm {
+	vector := make([]weightedTerm, 0, len(counts))
+	for term, count := range counts {
+		weight := float64(count) * math.Log(float64(docs)/float64(docFreq[term]))
+		if weight > 0 {
+			vector = append(vector, weightedTerm{term, weight})
+		}
+	}
+
+	sort.Slice(vector, func(i, j int) bool {
+		if vector[i].weight != vector[j].weight {
+			return vector[i].weight > vector[j].weight
+		}
+		return vector[i].term < vector[j].term
+	})
+	if len(vector) > maxRelatedTerms {
+		vector = vector[:maxRelatedTerms]
+	}
+
+	norm := 0.0
+	for _, wt := range vector {
+		norm += wt.weight * wt.weight
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i].weight /= norm
+	}
+	return vector
+}
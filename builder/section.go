@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Section is a folder inside posts/. Its title and description can be set
+// with an optional _section.yaml file inside the folder.
+type Section struct {
+	Name        string `yaml:"-"` // Path relative to posts/, e.g. guides/go
+	Title       string `yaml:"Title"`
+	Description string `yaml:"Description"`
+	URL         string `yaml:"-"`
+}
+
+func loadSection(dir string, name string) (*Section, error) {
+	name = filepath.ToSlash(name)
+	section := &Section{
+		Name:  name,
+		Title: strings.ReplaceAll(path.Base(name), "-", " "),
+		URL:   fmt.Sprintf("/post/%s/", name),
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "_section.yaml"))
+	if os.IsNotExist(err) {
+		return section, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, section); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, "_section.yaml"), err)
+	}
+	return section, nil
+}
+
+// StartSectionPageBuilder writes a listing page for every section containing
+// the posts directly inside that folder. Without templates/section.html,
+// sections have no listing page.
+func (b *Builder) StartSectionPageBuilder(posts PostList) {
+	defer b.staticFilesCreated.Done()
+
+	if len(b.site.Sections) == 0 || b.sectionTemplate == nil {
+		return
+	}
+
+	sectionMap := map[string]PostList{}
+	for post := range posts.Iterator() {
+		if post.Section != nil {
+			sectionMap[post.Section.Name] = append(sectionMap[post.Section.Name], post)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(b.site.Sections))
+	for name, section := range b.site.Sections {
+		go func() {
+			defer wg.Done()
+			b.buildSectionHTML(section, sectionMap[name])
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *Builder) buildSectionHTML(section *Section, sectionPosts PostList) {
+	var doc bytes.Buffer
+	data := struct {
+		Section *Section
+		Posts   PostList
+	}{
+		Section: section,
+		Posts:   sectionPosts,
+	}
+	err := b.sectionTemplate.Execute(&doc, data)
+	if err != nil {
+		panic(err)
+	}
+
+	b.sitemap.AddPageURL(section.URL)
+
+	outPath := outputPathForSlug(section.URL)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(outPath, doc.Bytes(), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
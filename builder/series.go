@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Series groups the posts sharing a `Series` front matter value. Parts are
+// ordered by `SeriesOrder`, then by date.
+type Series struct {
+	Name  string
+	URL   string
+	Parts PostList
+}
+
+// buildSeries groups the sorted posts into series.
+func buildSeries(posts PostList) map[string]*Series {
+	seriesMap := map[string]*Series{}
+	for _, post := range posts {
+		if post.SeriesName == "" {
+			continue
+		}
+		series, ok := seriesMap[post.SeriesName]
+		if !ok {
+			series = &Series{
+				Name: post.SeriesName,
+				URL:  fmt.Sprintf("/series/%s", tagSlug(post.SeriesName)),
+			}
+			seriesMap[post.SeriesName] = series
+		}
+		series.Parts = append(series.Parts, post)
+	}
+
+	for _, series := range seriesMap {
+		sort.SliceStable(series.Parts, func(i, j int) bool {
+			a, b := series.Parts[i], series.Parts[j]
+			if a.SeriesOrder != b.SeriesOrder {
+				return a.SeriesOrder < b.SeriesOrder
+			}
+			return a.PublishedAt.Before(b.PublishedAt)
+		})
+	}
+	return seriesMap
+}
+
+// linkSeries fills in the series fields of a post.
+func linkSeries(post *Post, seriesMap map[string]*Series) {
+	series, ok := seriesMap[post.SeriesName]
+	if !ok {
+		return
+	}
+	post.Series = series
+	for i, part := range series.Parts {
+		if part.Slug != post.Slug {
+			continue
+		}
+		post.SeriesPosition = i + 1
+		if i > 0 {
+			post.SeriesPrev = &series.Parts[i-1]
+		}
+		if i < len(series.Parts)-1 {
+			post.SeriesNext = &series.Parts[i+1]
+		}
+	}
+}
+
+// StartSeriesPageBuilder writes a landing page per series listing its parts
+// in order. Using Series without templates/series.html fails the build.
+func (b *Builder) StartSeriesPageBuilder(seriesMap map[string]*Series) {
+	defer b.staticFilesCreated.Done()
+
+	if len(seriesMap) == 0 {
+		return
+	}
+	if b.seriesTemplate == nil {
+		b.addBuildError(errors.New("posts use Series but templates/series.html is missing"))
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(seriesMap))
+	for _, series := range seriesMap {
+		go func() {
+			defer wg.Done()
+			b.buildSeriesHTML(series)
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *Builder) buildSeriesHTML(series *Series) {
+	var doc bytes.Buffer
+	err := b.seriesTemplate.Execute(&doc, series)
+	if err != nil {
+		panic(err)
+	}
+
+	b.sitemap.AddPageURL(series.URL)
+
+	outPath := outputPathForSlug(series.URL)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(outPath, doc.Bytes(), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,307 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Shortcodes ({{< name key="value" >}} and {{< name >}}inner{{< /name >}})
+// are cut out of the markdown before it is parsed and replaced with
+// placeholders. Once the post is linked to the rest of the site,
+// expandShortcodes renders each one with a Go function from
+// Builder.Shortcodes or templates/shortcodes/<name>.html and swaps it in.
+// {{</* name */>}} is written out literally.
+
+// ShortcodeContext is passed to shortcode functions and templates.
+type ShortcodeContext struct {
+	Name  string
+	Args  map[string]string // Named arguments, e.g. {{< youtube id="abc" >}} -> Args.id
+	Inner template.HTML     // Rendered markdown between the opening and closing tag
+	Post  *Post             // The post being rendered; nil on pages and collection items
+	Page  *Page             // The page being rendered; nil on posts and collection items
+	File  string            // Source file, relative to the input directory
+	Line  int
+}
+
+// ShortcodeFunc renders a shortcode in Go. It is registered through
+// Builder.Shortcodes (entrypoint.EasyblogOpts.Shortcodes) and takes
+// precedence over a template of the same name.
+type ShortcodeFunc func(ctx ShortcodeContext) (template.HTML, error)
+
+type shortcodeCall struct {
+	Name      string
+	Args      map[string]string
+	Inner     string // Raw markdown; only set when HasInner
+	HasInner  bool
+	Line      int
+	InnerLine int
+
+	idPrefix string // Footnote id prefix of the inner content
+	offset   int    // Position of the placeholder in the extracted source
+	lines    int    // Line breaks in the shortcode, which the placeholder drops
+}
+
+var shortcodeTagPattern = regexp.MustCompile(`(?s)\{\{<(/\*)?\s*(/?)([\w.-]+)(.*?)\s*(\*/)?>\}\}`)
+
+var shortcodeArgPattern = regexp.MustCompile(`^([\w.-]+)=("(?:[^"\\]|\\.)*"|'[^']*'|[^\s"']+)`)
+
+// Placeholders are built from private use characters, which goldmark passes
+// through untouched and which are neither letters nor digits, so they don't
+// show up as words when posts are compared for related posts.
+const (
+	shortcodeOpen  = '\uE000'
+	shortcodeClose = '\uE001'
+	shortcodeDigit = '\uE010' // '\uE010' + d encodes the decimal digit d
+)
+
+var shortcodePlaceholderPattern = regexp.MustCompile("(<p>)?\uE000([\uE010-\uE019]+)\uE001(</p>\n?)?")
+
+func shortcodePlaceholder(index int) string {
+	var placeholder strings.Builder
+	placeholder.WriteRune(shortcodeOpen)
+	for _, digit := range strconv.Itoa(index) {
+		placeholder.WriteRune(shortcodeDigit + digit - '0')
+	}
+	placeholder.WriteRune(shortcodeClose)
+	return placeholder.String()
+}
+
+func shortcodeIndex(digits string) int {
+	index := 0
+	for _, digit := range digits {
+		index = index*10 + int(digit-shortcodeDigit)
+	}
+	return index
+}
+
+type shortcodeTag struct {
+	start, end int
+	line       int
+	closing    bool
+	escaped    bool
+	name       string
+	args       string
+	pairedWith int // Index of the closing tag, -1 when standalone
+}
+
+// extractShortcodes replaces every top-level shortcode in source with a
+// placeholder. Shortcodes nested inside another's inner content are left in
+// place; they are extracted when the inner content is rendered. firstLine is
+// the line source starts on in its file, and idPrefix the footnote id prefix
+// of the document, which each shortcode's inner content extends.
+func extractShortcodes(source []byte, firstLine int, idPrefix string) ([]byte, []shortcodeCall, error) {
+	matches := shortcodeTagPattern.FindAllSubmatchIndex(source, -1)
+	if len(matches) == 0 {
+		return source, nil, nil
+	}
+
+	tags := make([]shortcodeTag, len(matches))
+	for i, m := range matches {
+		tags[i] = shortcodeTag{
+			start:      m[0],
+			end:        m[1],
+			line:       firstLine + bytes.Count(source[:m[0]], []byte("\n")),
+			escaped:    m[2] >= 0 && m[10] >= 0,
+			closing:    m[5] > m[4],
+			name:       string(source[m[6]:m[7]]),
+			args:       string(source[m[8]:m[9]]),
+			pairedWith: -1,
+		}
+	}
+
+	// Pair closing tags with the nearest open tag of the same name.
+	open := []int{}
+	for i, tag := range tags {
+		if tag.escaped {
+			continue
+		}
+		if !tag.closing {
+			open = append(open, i)
+			continue
+		}
+		matched := false
+		for j := len(open) - 1; j >= 0; j-- {
+			if tags[open[j]].name == tag.name {
+				tags[open[j]].pairedWith = i
+				open = open[:j]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, nil, fmt.Errorf("line %d: shortcode %s: closing tag without an opening one", tag.line, tag.name)
+		}
+	}
+
+	var out bytes.Buffer
+	calls := []shortcodeCall{}
+	last := 0
+	for i := 0; i < len(tags); i++ {
+		tag := tags[i]
+		out.Write(source[last:tag.start])
+		last = tag.end
+
+		if tag.escaped {
+			out.WriteString("{{< ")
+			if tag.closing {
+				out.WriteString("/")
+			}
+			out.WriteString(strings.TrimSpace(tag.name + tag.args))
+			out.WriteString(" >}}")
+			continue
+		}
+
+		args, err := parseShortcodeArgs(tag.args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: shortcode %s: %w", tag.line, tag.name, err)
+		}
+		call := shortcodeCall{Name: tag.name, Args: args, Line: tag.line, idPrefix: fmt.Sprintf("%ssc%d-", idPrefix, len(calls))}
+		if tag.pairedWith >= 0 {
+			closing := tags[tag.pairedWith]
+			inner := source[tag.end:closing.start]
+			call.HasInner = true
+			call.Inner = string(inner)
+			call.InnerLine = tag.line + bytes.Count(source[tag.start:tag.end], []byte("\n"))
+			last = closing.end
+			i = tag.pairedWith
+		}
+		call.offset = out.Len()
+		call.lines = bytes.Count(source[tag.start:last], []byte("\n"))
+		out.WriteString(shortcodePlaceholder(len(calls)))
+		calls = append(calls, call)
+	}
+	out.Write(source[last:])
+	return out.Bytes(), calls, nil
+}
+
+// parseShortcodeArgs reads key="value", key='value' and key=value pairs.
+func parseShortcodeArgs(raw string) (map[string]string, error) {
+	args := map[string]string{}
+	rest := strings.TrimSpace(raw)
+	for rest != "" {
+		m := shortcodeArgPattern.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("expected name=\"value\", got %q", rest)
+		}
+		value := m[2]
+		switch value[0] {
+		case '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("argument %s: %w", m[1], err)
+			}
+			value = unquoted
+		case '\'':
+			value = value[1 : len(value)-1]
+		}
+		args[m[1]] = value
+		rest = strings.TrimSpace(rest[len(m[0]):])
+	}
+	return args, nil
+}
+
+// loadShortcodeTemplates parses every templates/shortcodes/<name>.html.
+func (b *Builder) loadShortcodeTemplates(inputDirectory string) map[string]*template.Template {
+	templates := map[string]*template.Template{}
+	files, err := filepath.Glob(filepath.Join(inputDirectory, "templates", "shortcodes", "*.html"))
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		templates[name] = template.Must(template.New(filepath.Base(file)).Funcs(b.getFuncsMap()).ParseFiles(file))
+	}
+	return templates
+}
+
+// expandShortcodes renders the shortcodes of a document into its body.
+// Failures are recorded as build errors pointing at file and line.
+func (b *Builder) expandShortcodes(file string, body template.HTML, calls []shortcodeCall, post *Post, page *Page) template.HTML {
+	if len(calls) == 0 {
+		return body
+	}
+
+	expanded := shortcodePlaceholderPattern.ReplaceAllStringFunc(string(body), func(match string) string {
+		parts := shortcodePlaceholderPattern.FindStringSubmatch(match)
+		index := shortcodeIndex(parts[2])
+		if index >= len(calls) {
+			return match
+		}
+		out := string(b.renderShortcode(file, calls[index], post, page))
+
+		// A shortcode on a line of its own replaces the whole paragraph;
+		// inline, the surrounding paragraph is kept.
+		if parts[1] != "" && parts[3] != "" {
+			return out + "\n"
+		}
+		return parts[1] + out + parts[3]
+	})
+	return template.HTML(expanded)
+}
+
+func (b *Builder) renderShortcode(file string, call shortcodeCall, post *Post, page *Page) template.HTML {
+	fail := func(err error) template.HTML {
+		b.addBuildError(fmt.Errorf("%s: line %d: shortcode %s: %v", file, call.Line, call.Name, err))
+		return ""
+	}
+
+	ctx := ShortcodeContext{
+		Name: call.Name,
+		Args: call.Args,
+		Post: post,
+		Page: page,
+		File: file,
+		Line: call.Line,
+	}
+
+	if call.HasInner {
+		content, line := call.Inner, call.InnerLine
+		if strings.HasPrefix(content, "\n") {
+			// The content starts on the line after the opening tag.
+			content, line = content[1:], line+1
+		}
+		source, nested, err := extractShortcodes([]byte(content), line, call.idPrefix)
+		if err != nil {
+			return fail(err)
+		}
+		// Footnotes in the inner content get ids of their own, so they don't
+		// clash with the document's.
+		context := parser.NewContext()
+		doc := b.markdown.Parser().Parse(text.NewReader(source), parser.WithContext(context))
+		doc.SetAttributeString(footnotePrefixAttribute, []byte(call.idPrefix))
+		var inner bytes.Buffer
+		if err := b.markdown.Renderer().Render(&inner, source, doc); err != nil {
+			return fail(err)
+		}
+		logWarnings(file, parseWarnings(context, source, line-1, nested))
+		ctx.Inner = b.expandShortcodes(file, template.HTML(inner.String()), nested, post, page)
+	}
+
+	if fn, ok := b.Shortcodes[call.Name]; ok {
+		out, err := fn(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		return out
+	}
+
+	tmpl, ok := b.shortcodeTemplates[call.Name]
+	if !ok {
+		return fail(fmt.Errorf("unknown shortcode; add templates/shortcodes/%s.html", call.Name))
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, ctx); err != nil {
+		return fail(err)
+	}
+	// Template files usually end in a newline, which would break up inline
+	// shortcodes.
+	return template.HTML(strings.TrimSpace(out.String()))
+}
@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"encoding/json"
+	"html/template"
+	"time"
+)
+
+// structuredData renders a schema.org BlogPosting JSON-LD script for a post,
+// e.g. {{ structuredData . }} inside the <head> of post.html.
+func (b *Builder) structuredData(post Post) template.HTML {
+	data := map[string]any{
+		"@context":    "https://schema.org",
+		"@type":       "BlogPosting",
+		"headline":    post.Title,
+		"description": post.Summary,
+		"url":         b.Config.BaseURL + post.Slug,
+		"image":       post.OGImageURL,
+	}
+	if post.Author != "" {
+		data["author"] = map[string]string{
+			"@type": "Person",
+			"name":  post.Author,
+		}
+	}
+	if !post.PublishedAt.IsZero() {
+		data["datePublished"] = post.PublishedAt.Format(time.RFC3339)
+	}
+	if !post.UpdatedAt.IsZero() {
+		data["dateModified"] = post.UpdatedAt.Format(time.RFC3339)
+	}
+
+	// json.Marshal escapes <, > and &, so the output is safe inside <script>.
+	out, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	return template.HTML(`<script type="application/ld+json">` + string(out) + `</script>`)
+}
@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tag describes a tag for tag.html and tags.html. DisplayName, Description
+// and Color come from the optional data/tags.yaml, keyed by tag name:
+//
+//	Go:
+//	  DisplayName: Golang
+//	  Description: Posts about Go.
+//	  Color: "#00ADD8"
+type Tag struct {
+	Name        string `yaml:"-"`
+	DisplayName string `yaml:"DisplayName"`
+	Description string `yaml:"Description"`
+	Color       string `yaml:"Color"`
+	URL         string `yaml:"-"`
+	Count       int    `yaml:"-"`
+}
+
+// tagSlug turns a tag name into the path segment used under /tags/.
+func tagSlug(tagName string) string {
+	out := strings.ToLower(tagName)
+	out = strings.ReplaceAll(out, " ", "-")
+	return out
+}
+
+func loadTagData(inputDirectory string) (map[string]Tag, error) {
+	tags := map[string]Tag{}
+
+	raw, err := os.ReadFile(filepath.Join(inputDirectory, "data", "tags.yaml"))
+	if os.IsNotExist(err) {
+		return tags, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, &tags); err != nil {
+		return nil, fmt.Errorf("data/tags.yaml: %w", err)
+	}
+	return tags, nil
+}
+
+// tagInfo merges the data/tags.yaml entry for a tag with its URL and count.
+func tagInfo(tagData map[string]Tag, tagName string, count int) Tag {
+	tag := tagData[tagName]
+	tag.Name = tagName
+	if tag.DisplayName == "" {
+		tag.DisplayName = tagName
+	}
+	tag.URL = fmt.Sprintf("/tags/%s", tagSlug(tagName))
+	tag.Count = count
+	return tag
+}
+
+// buildTagIndexHTML writes out/tags/index.html listing every tag. Nothing is
+// generated when tags.html is missing.
+func (b *Builder) buildTagIndexHTML(tags []Tag) {
+	if b.tagIndexTemplate == nil {
+		return
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].DisplayName) < strings.ToLower(tags[j].DisplayName)
+	})
+
+	var doc bytes.Buffer
+	data := struct {
+		Tags []Tag
+	}{
+		Tags: tags,
+	}
+	err := b.tagIndexTemplate.Execute(&doc, data)
+	if err != nil {
+		panic(err)
+	}
+
+	b.sitemap.AddPageURL("/tags/")
+
+	err = os.WriteFile("./out/tags/index.html", doc.Bytes(), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// parseTerms reads a taxonomy front matter field such as Tags, written either
+// as a comma separated string ("Go, Web") or a YAML list ([Go, Web]). Terms
+// are trimmed, mapped to their canonical name through aliases (matched
+// case-insensitively) and deduplicated by URL.
+func parseTerms(value any, aliases map[string]string) []string {
+	raw := []string{}
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []any:
+		for _, item := range v {
+			raw = append(raw, fmt.Sprint(item))
+		}
+	case []string:
+		raw = v
+	}
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range raw {
+		tag = canonicalTag(strings.TrimSpace(tag), aliases)
+		if tag == "" || seen[tagSlug(tag)] {
+			continue
+		}
+		seen[tagSlug(tag)] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func canonicalTag(tag string, aliases map[string]string) string {
+	for alias, canonical := range aliases {
+		if strings.EqualFold(alias, tag) {
+			return canonical
+		}
+	}
+	return tag
+}
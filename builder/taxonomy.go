@@ -0,0 +1,174 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kvizdos/easyblog/feed"
+)
+
+// Term is a single value of a taxonomy, e.g. "Tutorials" in Categories.
+type Term struct {
+	Name  string
+	URL   string
+	Count int
+}
+
+// groupByTerm groups posts by the terms returned by termsOf, keeping the
+// order of posts. Terms that only differ in case share a URL, so they are
+// grouped under the first spelling seen.
+func groupByTerm(posts PostList, termsOf func(PostMetadata) []string) map[string]PostList {
+	termMap := map[string]PostList{}
+	termNames := map[string]string{}
+
+	for post := range posts.Iterator() {
+		for _, term := range termsOf(post) {
+			if name, ok := termNames[tagSlug(term)]; ok {
+				term = name
+			} else {
+				termNames[tagSlug(term)] = term
+			}
+			if v, ok := termMap[term]; ok {
+				termMap[term] = append(v, post)
+			} else {
+				termMap[term] = PostList{post}
+			}
+		}
+	}
+	return termMap
+}
+
+// StartTaxonomyPageBuilder writes a page (and optionally an Atom feed) per
+// term of every configured taxonomy, plus a term index per taxonomy.
+func (b *Builder) StartTaxonomyPageBuilder(posts PostList) {
+	defer b.staticFilesCreated.Done()
+
+	if errs := b.Config.checkTaxonomyPaths(); len(errs) > 0 {
+		for _, err := range errs {
+			b.addBuildError(err)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(b.Config.Taxonomies))
+	for _, taxonomy := range b.Config.Taxonomies {
+		go func() {
+			defer wg.Done()
+			b.buildTaxonomy(taxonomy, posts)
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *Builder) buildTaxonomy(taxonomy TaxonomyConfig, posts PostList) {
+	basePath := taxonomy.basePath()
+
+	termTemplate := b.parseTemplate(taxonomy.Template, "term.html")
+	indexTemplate := b.parseTemplate(taxonomy.IndexTemplate, "terms.html")
+
+	termMap := groupByTerm(posts, func(post PostMetadata) []string {
+		return parseTerms(post.RawMetadata[taxonomy.Name], nil)
+	})
+
+	terms := []Term{}
+	for termName, termPosts := range termMap {
+		term := Term{
+			Name:  termName,
+			URL:   fmt.Sprintf("%s/%s", basePath, tagSlug(termName)),
+			Count: len(termPosts),
+		}
+		terms = append(terms, term)
+
+		b.sitemap.AddPageURL(term.URL)
+
+		type termData struct {
+			Taxonomy  string
+			Term      Term
+			Posts     PostList
+			Paginator *Paginator // nil unless PageSize is set
+		}
+
+		pages := []*Paginator{{PageNumber: 1, TotalPages: 1, Items: termPosts, URL: term.URL}}
+		if b.Config.PageSize > 0 {
+			pages = paginate(termPosts, b.Config.PageSize, term.URL)
+		}
+		for _, page := range pages {
+			data := termData{
+				Taxonomy: taxonomy.Name,
+				Term:     term,
+				Posts:    page.Items,
+			}
+			if b.Config.PageSize > 0 {
+				data.Paginator = page
+			}
+
+			var doc bytes.Buffer
+			if err := termTemplate.Execute(&doc, data); err != nil {
+				panic(err)
+			}
+			b.writePaginatedPage(page, doc.Bytes())
+		}
+
+		if taxonomy.Feed {
+			b.writeFeed(fmt.Sprintf("%s: %s", taxonomy.Name, termName), term.URL, term.URL+".xml", termPosts)
+		}
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		return strings.ToLower(terms[i].Name) < strings.ToLower(terms[j].Name)
+	})
+
+	var doc bytes.Buffer
+	data := struct {
+		Taxonomy string
+		Terms    []Term
+	}{
+		Taxonomy: taxonomy.Name,
+		Terms:    terms,
+	}
+	if err := indexTemplate.Execute(&doc, data); err != nil {
+		panic(err)
+	}
+
+	b.sitemap.AddPageURL(basePath + "/")
+
+	outPath := outputPathForSlug(basePath + "/")
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(outPath, doc.Bytes(), 0644); err != nil {
+		panic(err)
+	}
+}
+
+// parseTemplate parses templates/<name>, or templates/<fallback> when name is
+// empty.
+func (b *Builder) parseTemplate(name string, fallback string) *template.Template {
+	if name == "" {
+		name = fallback
+	}
+	return template.Must(template.New(name).Funcs(b.getFuncsMap()).ParseFiles(filepath.Join(b.Config.InputDirectory, "templates", name)))
+}
+
+// writeFeed writes an Atom feed of posts to out/<feedPath>.
+func (b *Builder) writeFeed(title string, pagePath string, feedPath string, posts PostList) {
+	atom := feed.New(b.Config.BaseURL, title, pagePath, feedPath)
+	for _, post := range posts {
+		atom.AddEntry(post.Title, post.Slug, post.Author, post.Summary, post.PublishedAt, post.UpdatedAt)
+	}
+
+	outPath := filepath.Join("out", feedPath)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(outPath, atom.Marshal(), 0644); err != nil {
+		panic(err)
+	}
+}
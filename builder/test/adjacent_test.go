@@ -0,0 +1,56 @@
+package builder_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func adjacentSite(t *testing.T, scope string) string {
+	post := func(date string, tags string) string {
+		return "---\nDate: " + date + "\nTags: [" + tags + "]\n---\n\nText.\n"
+	}
+	return buildSite(t, &builder.Builder{Config: builder.Config{PrevNextScope: scope}}, map[string]string{
+		"templates/post.html": `prev={{ with .Prev }}{{ .Title }}{{ end }} next={{ with .Next }}{{ .Title }}{{ end }}`,
+		"posts/demo.md":       post("2020-01-01", "other"),
+		"posts/a.md":          post("2025-01-01", "go"),
+		"posts/b.md":          post("2025-01-02", "web"),
+		"posts/c.md":          post("2025-01-03", ""),
+		"posts/d.md":          post("2025-01-04", "Go, web"),
+	})
+}
+
+func TestAdjacentPosts(t *testing.T) {
+	tests := map[string]map[string]string{
+		"": {
+			"a": "prev=demo next=b",
+			"c": "prev=b next=d",
+			"d": "prev=c next=",
+		},
+		"tag": {
+			"a": "prev= next=d",
+			"b": "prev= next=d",
+			"c": "prev= next=",  // No tags
+			"d": "prev=b next=", // b shares web and is newer than a
+		},
+	}
+	for scope, want := range tests {
+		t.Run(fmt.Sprintf("scope %q", scope), func(t *testing.T) {
+			dir := adjacentSite(t, scope)
+			for post, want := range want {
+				if got := readOut(t, dir, "post/"+post+".html"); got != want {
+					t.Errorf("%s: got %q, want %q", post, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAdjacentUnknownScope(t *testing.T) {
+	err := buildSiteError(t, &builder.Builder{Config: builder.Config{PrevNextScope: "tags"}}, nil)
+	if !strings.Contains(err, `PrevNextScope: unknown scope "tags"`) {
+		t.Errorf("unexpected build error: %s", err)
+	}
+}
@@ -0,0 +1,55 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestArchive(t *testing.T) {
+	post := func(title string, date string) string {
+		if date != "" {
+			date = "Date: " + date + "\n"
+		}
+		return "---\nTitle: " + title + "\n" + date + "---\n\nText.\n"
+	}
+	b := &builder.Builder{Config: builder.Config{
+		Timezone:    "America/New_York",
+		FrontMatter: map[string]builder.FieldSchema{"Date": {Type: "date"}}, // Allow undated posts
+	}}
+	dir := buildSite(t, b, map[string]string{
+		"templates/archive.html": `{{ .Kind }}|{{ range .Years }}{{ .Year }}({{ .Count }}):{{ range .Months }} {{ .Month }}({{ .Count }}){{ end }};{{ end }}|{{ range .Posts }}{{ .Title }},{{ end }}`,
+		"posts/demo.md":          post("March", "2025-03-15"),
+		"posts/december.md":      post("December", "2024-12-10"),
+		"posts/january.md":       post("January", "2025-01-05"),
+		"posts/midnight.md":      post("Midnight", "2025-02-01T03:30:00Z"), // 22:30 on January 31 in New York
+		"posts/undated.md":       post("Undated", ""),
+	})
+
+	years := "2025(3): March(1) January(2);2024(1): December(1);"
+	for file, want := range map[string]string{
+		"archive/index.html": "overview|" + years + "|March,Midnight,January,December,",
+		"2025/index.html":    "year|" + years + "|March,Midnight,January,",
+		"2025/01/index.html": "month|" + years + "|Midnight,January,",
+		"2024/12/index.html": "month|" + years + "|December,",
+	} {
+		if got := readOut(t, dir, file); got != want {
+			t.Errorf("%s:\ngot  %q\nwant %q", file, got, want)
+		}
+	}
+	for _, file := range []string{"2025/02/index.html", "1/index.html"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", filepath.FromSlash(file))); err == nil {
+			t.Errorf("%s shouldn't be written", file)
+		}
+	}
+
+	sitemap := readOut(t, dir, "sitemap.xml")
+	for _, want := range []string{"/archive/</loc>", "/2025/</loc>", "/2025/01/</loc>", "/2024/12/</loc>"} {
+		if !strings.Contains(sitemap, want) {
+			t.Errorf("missing %q in sitemap.xml:\n%s", want, sitemap)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestPageBundles(t *testing.T) {
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"posts/x/index.md":         "---\nTitle: Bundle\nDate: 2025-01-01\n---\n\n![Photo](photo.png)\n",
+		"posts/x/photo.png":        "png",
+		"posts/x/drafts/notes.md":  "Not a post.",
+		"posts/x/img/diagram.svg":  "<svg></svg>",
+		"posts/guides/y/index.md":  "---\nTitle: Nested Bundle\nDate: 2025-01-02\n---\n\nText.\n",
+		"posts/guides/y/photo.jpg": "jpg",
+		"posts/guides/single.md":   "---\nTitle: Single\nDate: 2025-01-03\n---\n\nText.\n",
+		"templates/section.html":   `{{ range .Posts }}{{ .Slug }} {{ end }}`,
+	})
+
+	if html := readOut(t, dir, "post/x/index.html"); !strings.Contains(html, `<img src="photo.png" alt="Photo">`) {
+		t.Errorf("missing image in post/x/index.html:\n%s", html)
+	}
+	for file, want := range map[string]string{
+		"post/x/photo.png":        "png",
+		"post/x/img/diagram.svg":  "<svg></svg>",
+		"post/guides/y/photo.jpg": "jpg",
+	} {
+		if got := readOut(t, dir, file); got != want {
+			t.Errorf("%s: got %q, want %q", file, got, want)
+		}
+	}
+	for _, file := range []string{"post/x/index.md", "post/x/drafts/notes.md", "post/x/drafts/notes.html"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", filepath.FromSlash(file))); err == nil {
+			t.Errorf("%s shouldn't be written", file)
+		}
+	}
+
+	readOut(t, dir, "post/guides/y/index.html")
+	if section := readOut(t, dir, "post/guides/index.html"); !strings.Contains(section, "/post/guides/y/ ") {
+		t.Errorf("nested bundle missing from its section:\n%s", section)
+	}
+	sitemap := readOut(t, dir, "sitemap.xml")
+	for _, want := range []string{"<loc>https://example.com/post/x/</loc>", "<loc>https://example.com/post/guides/y/</loc>"} {
+		if !strings.Contains(sitemap, want) {
+			t.Errorf("missing %q in sitemap.xml:\n%s", want, sitemap)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestCollectionFeed(t *testing.T) {
+	b := &builder.Builder{Config: builder.Config{Collections: []builder.CollectionConfig{
+		{Name: "notes", Directory: "notes", Template: "note.html", Feed: true},
+		{Name: "talks", Directory: "talks", Template: "note.html"},
+	}}}
+	dir := buildSite(t, b, map[string]string{
+		"templates/note.html": `<h1>{{ .Title }}</h1>{{ .Body }}`,
+		"notes/older.md":      "---\nTitle: Older\nDate: 2025-01-01\n---\n\nText.\n",
+		"notes/newer.md":      "---\nTitle: Newer\nDate: 2025-02-01\nSummary: Latest\nAuthor: Kenton\n---\n\nText.\n",
+		"talks/talk.md":       "---\nTitle: Talk\nDate: 2025-01-01\n---\n\nText.\n",
+	})
+
+	atom := readOut(t, dir, "notes.xml")
+	for _, want := range []string{
+		`<link href="https://example.com/notes.xml" rel="self"></link>`,
+		`<id>https://example.com/notes/newer</id>`,
+		`<summary>Latest</summary>`,
+		`<name>Kenton</name>`,
+	} {
+		if !strings.Contains(atom, want) {
+			t.Errorf("missing %q in:\n%s", want, atom)
+		}
+	}
+	if strings.Index(atom, "<title>Newer</title>") > strings.Index(atom, "<title>Older</title>") {
+		t.Errorf("feed isn't newest first:\n%s", atom)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "talks.xml")); err == nil {
+		t.Error("talks.xml written without Feed: true")
+	}
+}
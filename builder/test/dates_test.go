@@ -0,0 +1,53 @@
+package builder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestParseDate(t *testing.T) {
+	cfg := builder.Config{Timezone: "America/New_York"}
+	loc, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		value any
+		want  time.Time
+	}{
+		{"03/15/2025", time.Date(2025, 3, 15, 0, 0, 0, 0, loc)},
+		{"2025-03-15", time.Date(2025, 3, 15, 0, 0, 0, 0, loc)},
+		{"2025-03-15T10:30:00Z", time.Date(2025, 3, 15, 6, 30, 0, 0, loc)},
+		{"2025-03-15 10:30", time.Date(2025, 3, 15, 10, 30, 0, 0, loc)},
+		{time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC), time.Date(2025, 3, 15, 8, 0, 0, 0, loc)},
+	}
+
+	for _, test := range tests {
+		got, err := cfg.ParseDate(test.value)
+		if err != nil {
+			t.Fatalf("ParseDate(%v): %v", test.value, err)
+		}
+		if !got.Equal(test.want) || got.Location().String() != loc.String() {
+			t.Errorf("ParseDate(%v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+
+	if _, err := cfg.ParseDate("not a date"); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+	if _, err := cfg.ParseDate(nil); err == nil {
+		t.Error("expected an error for a missing date")
+	}
+}
+
+func TestParseDateCustomLayout(t *testing.T) {
+	cfg := builder.Config{DateLayout: "02.01.2006"}
+
+	got, err := cfg.ParseDate("15.03.2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
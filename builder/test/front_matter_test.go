@@ -0,0 +1,85 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func parseTestPost(t *testing.T, cfg builder.Config, content string) (builder.Post, error) {
+	cfg.InputDirectory = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cfg.InputDirectory, "posts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.InputDirectory, "posts", "post.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	postsChan := make(chan builder.Post, 1)
+	metadataChan := make(chan builder.PostMetadata, 1)
+	err := builder.ParsePost(postsChan, metadataChan, builder.NewMarkdown(cfg, builder.MarkdownExtensions{}), cfg, "post.md", nil, nil)
+	return <-postsChan, err
+}
+
+func TestFrontMatterSchema(t *testing.T) {
+	cfg := builder.Config{
+		FrontMatter: map[string]builder.FieldSchema{
+			"Summary": {Required: true},
+			"Author":  {Default: "Site Owner"},
+			"Status":  {Allowed: []string{"draft", "published"}},
+		},
+	}
+
+	post, err := parseTestPost(t, cfg, "---\nDate: 2025-03-15\nSummary: s\nStatus: draft\n---\nbody\n")
+	if err != nil {
+		t.Fatalf("valid post: %v", err)
+	}
+	if post.Author != "Site Owner" {
+		t.Errorf("Author = %q, want the default", post.Author)
+	}
+
+	_, err = parseTestPost(t, cfg, "---\nTitle: 5\nSeriesOrder: abc\nStatus: wip\n---\nbody\n")
+	if err == nil {
+		t.Fatal("invalid post: expected errors")
+	}
+	for _, want := range []string{
+		"posts/post.md: front matter Date: required field is missing",
+		"posts/post.md: front matter Summary: required field is missing",
+		"posts/post.md: front matter Title: expected a string",
+		"posts/post.md: front matter SeriesOrder: expected a whole number",
+		`posts/post.md: front matter Status: "wip" is not one of draft, published`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("errors missing %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestFrontMatterFormats(t *testing.T) {
+	cfg := builder.Config{Timezone: "America/New_York"}
+	sources := map[string]string{
+		"yaml": "---\nTitle: Imported\nDate: 2025-03-15\nTags: [Go, Web]\nSeriesOrder: 2\nSyndications:\n  bluesky: https://b\n---\nbody\n",
+		"toml": "+++\ntitle = \"Imported\"\ndate = 2025-03-15\ntags = [\"Go\", \"Web\"]\nSeriesOrder = 2\n[Syndications]\nbluesky = \"https://b\"\n+++\nbody\n",
+		"json": "{\n  \"Title\": \"Imported\",\n  \"Date\": \"2025-03-15\",\n  \"Tags\": [\"Go\", \"Web\"],\n  \"SeriesOrder\": 2,\n  \"Syndications\": {\"bluesky\": \"https://b\"}\n}\nbody\n",
+	}
+
+	for format, source := range sources {
+		post, err := parseTestPost(t, cfg, source)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if post.Title != "Imported" || post.SeriesOrder != 2 || strings.Join(post.Tags, ",") != "Go,Web" || post.Syndications["bluesky"] != "https://b" {
+			t.Errorf("%s: got %+v", format, post)
+		}
+		if post.PublishedAt.Format("2006-01-02 15:04 MST") != "2025-03-15 00:00 EDT" {
+			t.Errorf("%s: PublishedAt = %v", format, post.PublishedAt)
+		}
+		if strings.TrimSpace(string(post.Body)) != "<p>body</p>" {
+			t.Errorf("%s: Body = %q", format, post.Body)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package builder_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+// git runs a git command in dir, committing at date.
+func git(t *testing.T, dir string, date string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestGitDates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	repo := copySite(t, map[string]string{
+		"posts/undated.md": "---\nTitle: Undated\n---\n\nFirst draft.\n",
+	})
+	git(t, repo, "2024-01-02T12:00:00Z", "init", "-q")
+	git(t, repo, "2024-01-02T12:00:00Z", "add", "-A")
+	git(t, repo, "2024-01-02T12:00:00Z", "commit", "-q", "-m", "First")
+	if err := writeFile(filepath.Join(repo, "posts", "undated.md"), "---\nTitle: Undated\n---\n\nRevised.\n"); err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "2024-03-04T12:00:00Z", "commit", "-q", "-am", "Revise")
+
+	t.Run("full history", func(t *testing.T) {
+		buildIn(t, &builder.Builder{Config: builder.Config{GitDates: true}}, repo)
+		html := readOut(t, repo, "post/undated.html")
+		for _, want := range []string{"January 2, 2024", "(updated March 4, 2024)"} {
+			if !strings.Contains(html, want) {
+				t.Errorf("missing %q in:\n%s", want, html)
+			}
+		}
+	})
+
+	t.Run("shallow clone", func(t *testing.T) {
+		clone := filepath.Join(t.TempDir(), "clone")
+		git(t, repo, "2024-03-04T12:00:00Z", "clone", "-q", "--depth", "1", "file://"+repo, clone)
+		modified := time.Date(2023, 5, 6, 12, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(filepath.Join(clone, "posts", "undated.md"), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+
+		buildIn(t, &builder.Builder{Config: builder.Config{GitDates: true}}, clone)
+		html := readOut(t, clone, "post/undated.html")
+		if !strings.Contains(html, "May 6, 2023") || strings.Contains(html, "(updated") {
+			t.Errorf("expected the modification time and no update date in:\n%s", html)
+		}
+		if want := "is a shallow clone"; !strings.Contains(logged.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, logged.String())
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package builder_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kvizdos/easyblog/builder"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// linkClassTransformer marks every link with class="external".
+type linkClassTransformer struct{}
+
+func (linkClassTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*ast.Link); ok && entering {
+			link.SetAttributeString("class", []byte("external"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func TestMarkdownExtensions(t *testing.T) {
+	off := false
+	cfg := builder.Config{Markdown: builder.MarkdownConfig{GFM: &off}}
+	md := builder.NewMarkdown(cfg, builder.MarkdownExtensions{
+		Extenders:       []goldmark.Extender{extension.Typographer},
+		ASTTransformers: []util.PrioritizedValue{util.Prioritized(linkClassTransformer{}, 500)},
+	})
+
+	var out bytes.Buffer
+	source := "\"Quoted\" [link](/x) ~~struck~~\n"
+	if err := md.Convert([]byte(source), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	html := out.String()
+	if !strings.Contains(html, "&ldquo;Quoted&rdquo;") {
+		t.Errorf("typographer extension not applied: %s", html)
+	}
+	if !strings.Contains(html, `<a href="/x" class="external">`) {
+		t.Errorf("AST transformer not applied: %s", html)
+	}
+	if strings.Contains(html, "<del>") {
+		t.Errorf("GFM should be off: %s", html)
+	}
+}
+
+func TestCallouts(t *testing.T) {
+	cfg := builder.Config{Markdown: builder.MarkdownConfig{
+		CalloutTypes: map[string]string{"info": "Did you know?", "caution": ""},
+	}}
+	md := builder.NewMarkdown(cfg, builder.MarkdownExtensions{})
+
+	source := strings.Join([]string{
+		"> [!WARNING] Back up first",
+		"> Deletes **everything**.",
+		"",
+		"> [!CAUTION]",
+		"> Turned off.",
+		"",
+		"::::info",
+		"Outer",
+		"",
+		":::note",
+		"Inner",
+		":::",
+		"::::",
+		"",
+		":::{.other}",
+		"Plain fence",
+		":::",
+		"",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := md.Convert([]byte(source), &out); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+
+	for _, want := range []string{
+		`<aside class="callout callout-warning" role="note">` + "\n" +
+			`<p class="callout-title"><span class="callout-icon" aria-hidden="true"></span>Back up first</p>` + "\n" +
+			`<div class="callout-body">` + "\n" +
+			"<p>Deletes <strong>everything</strong>.</p>\n</div>\n</aside>",
+		"<blockquote>\n<p>[!CAUTION]",
+		`<p class="callout-title"><span class="callout-icon" aria-hidden="true"></span>Did you know?</p>` + "\n" +
+			`<div class="callout-body">` + "\n<p>Outer</p>\n" + `<aside class="callout callout-note" role="note">`,
+		`<div data-fence="0" class="other">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+}
+
+func TestSidenotes(t *testing.T) {
+	b := &builder.Builder{Config: builder.Config{Markdown: builder.MarkdownConfig{Sidenotes: true}}}
+	source := strings.Join([]string{
+		"Short[^a] and long[^b], short again[^a].",
+		"",
+		"## fn 1",
+		"",
+		"[^a]: A *note*.",
+		"[^b]: A list:",
+		"",
+		"    - one",
+		"",
+	}, "\n")
+	dir := buildSite(t, b, map[string]string{
+		"posts/notes.md": "---\nTitle: Notes\nDate: 2024-01-02\n---\n" + source,
+	})
+	html := readOut(t, dir, "post/notes.html")
+
+	for _, want := range []string{
+		`<sup id="notes-fnref:1" class="sidenote-ref"><a href="#notes-fn:1" class="footnote-ref" role="doc-noteref" aria-describedby="notes-sn:1">1</a></sup>` +
+			`<span class="sidenote" id="notes-sn:1" role="note"><span class="sidenote-number">1</span> A <em>note</em>.</span>`,
+		`<sup id="notes-fnref:2" class="sidenote-ref"><a href="#notes-fn:2" class="footnote-ref" role="doc-noteref">2</a></sup>,`,
+		`<sup id="notes-fnref1:1" class="sidenote-ref"><a href="#notes-fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>.`,
+		`<h2 id="fn-1">`,
+		`<div class="footnotes sidenote-fallback" role="doc-endnotes">`,
+		`<li id="notes-fn:1" class="has-sidenote">`,
+		`<li id="notes-fn:2">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+}
+
+func TestMath(t *testing.T) {
+	source := "Inline $E = mc^2$, prices $5 and $10, code `$x$`.\n\n$$\n\\frac{1}{2}\n$$\n"
+
+	for _, tt := range []struct {
+		output string
+		want   []string
+	}{
+		{"", []string{
+			`Inline <math class="math-textstyle" display="inline"`,
+			`<annotation encoding="application/x-tex">E = mc^2</annotation></semantics></math>, prices $5 and $10, code <code>$x$</code>.`,
+			`<math class="math-displaystyle" display="block" displaystyle="true"`,
+			`<mfrac><mn>1</mn><mn>2</mn></mfrac>`,
+		}},
+		{"tex", []string{
+			`Inline <span class="math math-inline">\(E = mc^2\)</span>, prices $5 and $10`,
+			`<div class="math math-display">\[\frac{1}{2}\]</div>`,
+		}},
+	} {
+		md := builder.NewMarkdown(builder.Config{Markdown: builder.MarkdownConfig{MathOutput: tt.output}}, builder.MarkdownExtensions{})
+		var out bytes.Buffer
+		if err := md.Convert([]byte(source), &out); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("MathOutput %q: missing %q in:\n%s", tt.output, want, out.String())
+			}
+		}
+	}
+}
+
+func TestMathWarnings(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"posts/math.md": "+++\ntitle = \"Math\"\ndate = 2024-01-02\n+++\n\nFine $x$.\n\nBroken $\\frac{1}{$.\n",
+	})
+
+	if want := `warning: posts/math.md: line 8: math: mismatched curly brace`; !strings.Contains(logged.String(), want) {
+		t.Errorf("missing %q in:\n%s", want, logged.String())
+	}
+	html := readOut(t, dir, "post/math.html")
+	if want := `Broken <span class="math math-inline math-error">\(\frac{1}{\)</span>.`; !strings.Contains(html, want) {
+		t.Errorf("missing %q in:\n%s", want, html)
+	}
+}
+
+func TestMathWarningsAfterShortcodes(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	buildSite(t, &builder.Builder{}, map[string]string{
+		"templates/shortcodes/note.html": `<aside class="note">{{ .Inner }}</aside>` + "\n",
+		"posts/math.md": "---\nTitle: Math\nDate: 2024-01-02\n---\n\n" +
+			"{{< note >}}\nInner $\\frac{2}{$ here.\n\nMore.\n{{< /note >}}\n\n" +
+			"Broken $\\frac{1}{$.\n",
+	})
+
+	for _, want := range []string{
+		`warning: posts/math.md: line 7: math: mismatched curly brace`,
+		`warning: posts/math.md: line 12: math: mismatched curly brace`,
+	} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, logged.String())
+		}
+	}
+}
+
+func TestDiagrams(t *testing.T) {
+	// Rendered diagrams are cached for the life of the process, so each run
+	// uses sources of its own.
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 10)
+	calls := 0
+	cacheDir := filepath.Join(t.TempDir(), "diagrams")
+	cfg := builder.Config{Markdown: builder.MarkdownConfig{DiagramCache: cacheDir}}
+	md := builder.NewMarkdown(cfg, builder.MarkdownExtensions{
+		Diagrams: map[string]builder.DiagramFunc{
+			"boxes": func(source []byte) ([]byte, error) {
+				calls++
+				return []byte("<svg>" + strings.TrimSpace(string(source)) + "</svg>\n"), nil
+			},
+			"broken": func(source []byte) ([]byte, error) {
+				return nil, errors.New("syntax error")
+			},
+		},
+	})
+
+	source := strings.Join([]string{
+		"```goat", "+--+", "|  |", "+--+", nonce, "```",
+		"```boxes", "box " + nonce, "```",
+		"```boxes", "box " + nonce, "```",
+		"```broken", "nope", "```",
+		"```go", "func main() {}", "```",
+		"",
+	}, "\n")
+	var out bytes.Buffer
+	if err := md.Convert([]byte(source), &out); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+
+	for _, want := range []string{
+		"<div class=\"diagram diagram-goat\">\n<svg class='diagram'",
+		"<div class=\"diagram diagram-boxes\">\n<svg>box " + nonce + "</svg>\n</div>",
+		"nope",                     // Failed diagrams stay code blocks
+		`<pre tabindex="0" style=`, // Highlighting still applies to other code
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+	if strings.Count(html, `<pre`) != 2 {
+		t.Errorf("expected only the broken and go blocks to be highlighted:\n%s", html)
+	}
+	if calls != 1 {
+		t.Errorf("identical diagrams should be rendered once, got %d renders", calls)
+	}
+	if files, _ := os.ReadDir(cacheDir); len(files) != 2 {
+		t.Errorf("expected the goat and boxes SVGs in %s, got %d files", cacheDir, len(files))
+	}
+}
+
+func TestPikchrIsOptIn(t *testing.T) {
+	source := []byte("```pikchr\nbox \"pikchr\"\n```\n")
+	convert := func(extra builder.MarkdownExtensions) string {
+		var out bytes.Buffer
+		if err := builder.NewMarkdown(builder.Config{}, extra).Convert(source, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	if html := convert(builder.MarkdownExtensions{}); strings.Contains(html, "diagram-pikchr") || !strings.Contains(html, "<pre") {
+		t.Errorf("pikchr should stay a code block without a renderer:\n%s", html)
+	}
+
+	html := convert(builder.MarkdownExtensions{Diagrams: map[string]builder.DiagramFunc{
+		"pikchr": func(source []byte) ([]byte, error) {
+			return []byte("<svg>" + string(bytes.TrimSpace(source)) + "</svg>"), nil
+		},
+	}})
+	if want := "<div class=\"diagram diagram-pikchr\">\n<svg>box \"pikchr\"</svg>\n</div>"; !strings.Contains(html, want) {
+		t.Errorf("missing %q in:\n%s", want, html)
+	}
+}
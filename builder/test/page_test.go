@@ -0,0 +1,61 @@
+package builder_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestPages(t *testing.T) {
+	var mu sync.Mutex
+	ogImages := map[string]string{}
+	b := &builder.Builder{OGGenerator: func(title string, outPath string, _ builder.OGImageConfig) {
+		mu.Lock()
+		ogImages[outPath] = title
+		mu.Unlock()
+	}}
+	dir := buildSite(t, b, nil) // The example site has pages/about.md
+
+	html := readOut(t, dir, "about.html")
+	for _, want := range []string{
+		`<title>About - My Awesome Blog</title>`,
+		`<meta property="og:image" content="https://example.com/og_images/page-about.png" />`,
+		`<h2 id="hello">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in about.html:\n%s", want, html)
+		}
+	}
+	if sitemap := readOut(t, dir, "sitemap.xml"); !strings.Contains(sitemap, "<loc>https://example.com/about</loc>") {
+		t.Errorf("/about missing from sitemap.xml:\n%s", sitemap)
+	}
+	if title := ogImages["./out/og_images/page-about.png"]; title != "About" {
+		t.Errorf("OG image for about.html: got title %q, generated %v", title, ogImages)
+	}
+}
+
+func TestReservedPageNames(t *testing.T) {
+	b := &builder.Builder{Config: builder.Config{Taxonomies: []builder.TaxonomyConfig{{Name: "Categories"}}}}
+	page := "---\nTitle: Reserved\n---\n\nText.\n"
+	err := buildSiteError(t, b, map[string]string{
+		"pages/index.md":      page,
+		"pages/Sitemap.md":    page,
+		"pages/archive.md":    page,
+		"pages/categories.md": page,
+	})
+	for _, want := range []string{
+		"pages/index.md: would be published at /index, which is reserved",
+		"pages/Sitemap.md: would be published at /Sitemap, which is reserved",
+		"pages/archive.md: would be published at /archive, which is reserved",
+		"pages/categories.md: would be published at /categories, which is reserved",
+	} {
+		if !strings.Contains(err, want) {
+			t.Errorf("missing %q in build error: %s", want, err)
+		}
+	}
+	if strings.Contains(err, "about.md") {
+		t.Errorf("about.md isn't reserved: %s", err)
+	}
+}
@@ -0,0 +1,44 @@
+package builder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestIndexPagination(t *testing.T) {
+	second := "---\nTitle: Second\nDate: 2025-04-02\n---\n\nText.\n"
+
+	t.Run("off", func(t *testing.T) {
+		// The example's index.html ranges over the post list itself.
+		dir := buildSite(t, &builder.Builder{}, map[string]string{
+			"posts/second.md": second,
+		})
+
+		index := readOut(t, dir, "index.html")
+		if !strings.Contains(index, `<p id="title">Second</p>`) {
+			t.Errorf("newest post missing from index.html:\n%s", index)
+		}
+	})
+
+	t.Run("on", func(t *testing.T) {
+		b := &builder.Builder{Config: builder.Config{PageSize: 1}}
+		dir := buildSite(t, b, map[string]string{
+			"posts/second.md": second,
+			"templates/index.html": `{{ range .Posts }}<p id="title">{{ .Title }}</p>{{ end }}
+{{ with .Paginator }}Page {{ .PageNumber }} of {{ .TotalPages }}{{ if .HasNext }}<a href="{{ .NextURL }}">Older</a>{{ end }}{{ end }}`,
+		})
+
+		index := readOut(t, dir, "index.html")
+		if !strings.Contains(index, `<p id="title">Second</p>`) {
+			t.Errorf("newest post missing from index.html:\n%s", index)
+		}
+		if !strings.Contains(index, `Page 1 of 2`) || !strings.Contains(index, `<a href="/page/2/">Older</a>`) {
+			t.Errorf("missing paginator in index.html:\n%s", index)
+		}
+		if page := readOut(t, dir, "page/2/index.html"); !strings.Contains(page, `Page 2 of 2`) {
+			t.Errorf("missing paginator in page/2/index.html:\n%s", page)
+		}
+	})
+}
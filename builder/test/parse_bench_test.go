@@ -0,0 +1,109 @@
+package builder_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/toc"
+)
+
+// benchmarkCorpus writes the generator.sh corpus, 100 copies of the demo
+// post, to a temporary input directory.
+func benchmarkCorpus(b *testing.B) builder.Config {
+	demo, err := os.ReadFile(filepath.Join("..", "..", "example", "posts", "demo.md"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg := builder.Config{InputDirectory: b.TempDir(), CodeStyle: "dracula"}
+	if err := os.MkdirAll(filepath.Join(cfg.InputDirectory, "posts"), 0755); err != nil {
+		b.Fatal(err)
+	}
+	for i := 1; i <= 100; i++ {
+		if err := os.WriteFile(filepath.Join(cfg.InputDirectory, "posts", fmt.Sprintf("demo-%d.md", i)), demo, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return cfg
+}
+
+func parseCorpus(b *testing.B, cfg builder.Config, markdownFor func() goldmark.Markdown) {
+	postsChan := make(chan builder.Post, 1)
+	metadataChan := make(chan builder.PostMetadata, 1)
+	for i := 1; i <= 100; i++ {
+		if err := builder.ParsePost(postsChan, metadataChan, markdownFor(), cfg, fmt.Sprintf("demo-%d.md", i), nil, nil); err != nil {
+			b.Fatal(err)
+		}
+		<-postsChan
+		<-metadataChan
+	}
+}
+
+// parseCorpusTwice renders the corpus the way ParsePost used to: one parse
+// for the table of contents and a second one, through Convert, for the HTML.
+func parseCorpusTwice(b *testing.B, cfg builder.Config, markdownFor func() goldmark.Markdown) {
+	for i := 1; i <= 100; i++ {
+		source, err := os.ReadFile(filepath.Join(cfg.InputDirectory, "posts", fmt.Sprintf("demo-%d.md", i)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		md := markdownFor()
+
+		doc := md.Parser().Parse(text.NewReader(source))
+		tree, err := toc.Inspect(doc, source, toc.MinDepth(2), toc.MaxDepth(3))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if list := toc.RenderList(tree); list != nil {
+			if err := md.Renderer().Render(io.Discard, []byte{}, list); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		context := parser.NewContext()
+		if err := md.Convert(source, io.Discard, parser.WithContext(context)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := meta.TryGet(context); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParsePosts parses the 100-post corpus with one shared goldmark
+// pipeline (as Build does) and, for comparison, with a new pipeline per post
+// and with each post parsed twice, as before. per-post-two-parses is the old
+// ParsePost.
+func BenchmarkParsePosts(b *testing.B) {
+	cfg := benchmarkCorpus(b)
+	shared := builder.NewMarkdown(cfg, builder.MarkdownExtensions{})
+	perPost := func() goldmark.Markdown { return builder.NewMarkdown(cfg, builder.MarkdownExtensions{}) }
+
+	b.Run("shared", func(b *testing.B) {
+		for range b.N {
+			parseCorpus(b, cfg, func() goldmark.Markdown { return shared })
+		}
+	})
+	b.Run("per-post", func(b *testing.B) {
+		for range b.N {
+			parseCorpus(b, cfg, perPost)
+		}
+	})
+	b.Run("shared-two-parses", func(b *testing.B) {
+		for range b.N {
+			parseCorpusTwice(b, cfg, func() goldmark.Markdown { return shared })
+		}
+	})
+	b.Run("per-post-two-parses", func(b *testing.B) {
+		for range b.N {
+			parseCorpusTwice(b, cfg, perPost)
+		}
+	})
+}
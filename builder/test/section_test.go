@@ -0,0 +1,36 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestSections(t *testing.T) {
+	files := map[string]string{
+		"posts/guides/_section.yaml": "Title: Guides\n",
+		"posts/guides/setup.md":      "---\nTitle: Setup\nDate: 2025-01-01\n---\n\nText.\n",
+	}
+
+	t.Run("with section.html", func(t *testing.T) {
+		dir := buildSite(t, &builder.Builder{}, files)
+		if html := readOut(t, dir, "post/guides/index.html"); !strings.Contains(html, "Setup") {
+			t.Errorf("section page doesn't list its post:\n%s", html)
+		}
+	})
+
+	t.Run("without section.html", func(t *testing.T) {
+		dir := copySite(t, files)
+		if err := os.Remove(filepath.Join(dir, "templates", "section.html")); err != nil {
+			t.Fatal(err)
+		}
+		buildIn(t, &builder.Builder{}, dir)
+		readOut(t, dir, "post/guides/setup.html")
+		if _, err := os.Stat(filepath.Join(dir, "out", "post", "guides", "index.html")); err == nil {
+			t.Error("section page written without section.html")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func seriesPost(title string, date string, series string, order string) string {
+	frontMatter := "Title: " + title + "\nDate: " + date + "\nSeries: " + series + "\n"
+	if order != "" {
+		frontMatter += "SeriesOrder: " + order + "\n"
+	}
+	return "---\n" + frontMatter + "---\n\nText.\n"
+}
+
+func TestSeries(t *testing.T) {
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"templates/post.html":   `{{ with .Series }}{{ .Name }} {{ .URL }} {{ len .Parts }}{{ end }}|{{ .SeriesPosition }}|{{ with .SeriesPrev }}{{ .Title }}{{ end }}|{{ with .SeriesNext }}{{ .Title }}{{ end }}`,
+		"templates/series.html": `{{ .Name }}|{{ range .Parts }}{{ .Title }},{{ end }}`,
+		"posts/one.md":          seriesPost("One", "2025-03-01", "Go Basics", "1"), // Newest, but first by SeriesOrder
+		"posts/two.md":          seriesPost("Two", "2025-01-01", "Go Basics", "2"),
+		"posts/three-a.md":      seriesPost("Three A", "2025-01-15", "Go Basics", "3"),
+		"posts/three-b.md":      seriesPost("Three B", "2025-02-01", "Go Basics", "3"), // Same order, newer
+		"posts/later.md":        seriesPost("Later", "2025-02-01", "Notes", ""),
+		"posts/earlier.md":      seriesPost("Earlier", "2025-01-01", "Notes", ""),
+	})
+
+	for file, want := range map[string]string{
+		"post/one.html":         "Go Basics /series/go-basics 4|1||Two",
+		"post/two.html":         "Go Basics /series/go-basics 4|2|One|Three A",
+		"post/three-a.html":     "Go Basics /series/go-basics 4|3|Two|Three B",
+		"post/three-b.html":     "Go Basics /series/go-basics 4|4|Three A|",
+		"post/earlier.html":     "Notes /series/notes 2|1||Later",
+		"post/demo.html":        "|0||",
+		"series/go-basics.html": "Go Basics|One,Two,Three A,Three B,",
+		"series/notes.html":     "Notes|Earlier,Later,",
+	} {
+		if got := readOut(t, dir, file); got != want {
+			t.Errorf("%s:\ngot  %q\nwant %q", file, got, want)
+		}
+	}
+	if sitemap := readOut(t, dir, "sitemap.xml"); !strings.Contains(sitemap, "/series/go-basics</loc>") {
+		t.Errorf("series page missing from sitemap.xml:\n%s", sitemap)
+	}
+}
+
+func TestSeriesWithoutTemplate(t *testing.T) {
+	dir := copySite(t, map[string]string{
+		"posts/one.md": seriesPost("One", "2025-01-01", "Go Basics", "1"),
+	})
+	if err := os.Remove(filepath.Join(dir, "templates", "series.html")); err != nil {
+		t.Fatal(err)
+	}
+	err := build(t, &builder.Builder{}, dir)
+	if err == nil || !strings.Contains(err.Error(), "posts use Series but templates/series.html is missing") {
+		t.Errorf("unexpected build error: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package builder_test
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestShortcodes(t *testing.T) {
+	b := &builder.Builder{
+		Shortcodes: map[string]builder.ShortcodeFunc{
+			"upper": func(ctx builder.ShortcodeContext) (template.HTML, error) {
+				return template.HTML(fmt.Sprintf("<b>%s in %s</b>", strings.ToUpper(ctx.Args["text"]), ctx.Post.Title)), nil
+			},
+		},
+	}
+	dir := buildSite(t, b, map[string]string{
+		"templates/shortcodes/note.html": `<aside class="note {{ .Args.kind }}">{{ .Inner }}</aside>` + "\n",
+		"posts/shortcodes.md": "---\nTitle: Shortcodes\nDate: 2025-04-01\n---\n\n" +
+			"{{< note kind=\"tip\" >}}\nSome **markdown** and {{< upper text='nested' >}}.\n{{< /note >}}\n\n" +
+			"Literal `{{</* note */>}}`.\n",
+	})
+
+	html := readOut(t, dir, "post/shortcodes.html")
+	for _, want := range []string{
+		`<aside class="note tip"><p>Some <strong>markdown</strong> and <b>NESTED in Shortcodes</b>.</p>`,
+		`<code>{{&lt; note &gt;}}</code>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+}
+
+func TestShortcodeErrors(t *testing.T) {
+	err := buildSiteError(t, &builder.Builder{}, map[string]string{
+		"posts/broken.md": "---\nTitle: Broken\nDate: 2025-04-01\n---\n\ntext\n{{< missing >}}\n",
+	})
+	if !strings.Contains(err, "posts/broken.md: line 7: shortcode missing: unknown shortcode") {
+		t.Errorf("unexpected build error: %s", err)
+	}
+}
+
+func TestPageAndCollectionErrors(t *testing.T) {
+	b := &builder.Builder{Config: builder.Config{Collections: []builder.CollectionConfig{
+		{Name: "notes", Directory: "notes", Template: "note.html"},
+	}}}
+	err := buildSiteError(t, b, map[string]string{
+		"templates/note.html": `{{ .Body }}`,
+		"pages/uses.md":       "# Uses\n\n{{< /note >}}\n",
+		"notes/broken.md":     "+++\ntitle = \"Broken\"\n",
+	})
+	for _, want := range []string{
+		"pages/uses.md: line 3: shortcode note: closing tag without an opening one",
+		"notes/broken.md: TOML front matter: missing closing +++",
+	} {
+		if !strings.Contains(err, want) {
+			t.Errorf("missing %q in build error: %s", want, err)
+		}
+	}
+}
+
+func TestShortcodesFrontMatterAndFootnotes(t *testing.T) {
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"templates/shortcodes/note.html": `<aside class="note">{{ .Inner }}</aside>` + "\n",
+		"posts/notes.md": "---\nTitle: \"Writing {{< note >}}\"\nDate: 2025-04-01\n---\n\n" +
+			"Text.[^1]\n\n{{< note >}}\nInner.[^1]\n\n[^1]: Inner note.\n{{< /note >}}\n\n[^1]: Outer note.\n",
+	})
+
+	html := readOut(t, dir, "post/notes.html")
+	for _, want := range []string{
+		`Writing {{&lt; note &gt;}}`,
+		`id="notes-fn:1"`,
+		`id="notes-sc0-fn:1"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+}
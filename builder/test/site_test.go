@@ -0,0 +1,94 @@
+package builder_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+// buildSite builds a copy of the example site with files added (or
+// replaced) and returns the directory holding its out/ folder.
+func buildSite(t *testing.T, b *builder.Builder, files map[string]string) string {
+	dir := copySite(t, files)
+	buildIn(t, b, dir)
+	return dir
+}
+
+// buildSiteError builds a copy of the example site like buildSite, expecting
+// the build to fail, and returns its error message.
+func buildSiteError(t *testing.T, b *builder.Builder, files map[string]string) string {
+	err := build(t, b, copySite(t, files))
+	if err == nil {
+		t.Fatal("build should fail")
+	}
+	return err.Error()
+}
+
+// copySite copies the example site to a temporary directory with files added
+// (or replaced) and returns the directory.
+func copySite(t *testing.T, files map[string]string) string {
+	example, err := filepath.Abs(filepath.Join("..", "..", "example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = filepath.WalkDir(example, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) == ".go" {
+			return err
+		}
+		rel, _ := filepath.Rel(example, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(dir, rel), string(content))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// buildIn builds the site in dir into dir/out, failing the test on build
+// errors.
+func buildIn(t *testing.T, b *builder.Builder, dir string) {
+	if err := build(t, b, dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func build(t *testing.T, b *builder.Builder, dir string) error {
+	t.Chdir(dir)
+	b.MaxConcurrentPageBuilds = 5
+	b.Config.InputDirectory = "."
+	b.Config.BaseURL = "https://example.com"
+	b.Config.OGImageConfig = builder.OGImageConfig{IconPath: "./og/icon.jpg", FontPath: "./og/regular.ttf", FontSize: 92}
+	if b.OGGenerator == nil {
+		b.OGGenerator = func(string, string, builder.OGImageConfig) {}
+	}
+	return b.Build()
+}
+
+func writeFile(path string, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func readOut(t *testing.T, dir string, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, "out", filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
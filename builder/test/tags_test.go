@@ -0,0 +1,75 @@
+package builder_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestTagParsing(t *testing.T) {
+	tests := []struct {
+		name string
+		tags string // Front matter value
+		want string
+	}{
+		{"comma-separated", `"Go, Web"`, "[Go][Web]"},
+		{"list", `[Go, Web]`, "[Go][Web]"},
+		{"alias", `"golang, web"`, "[Go][web]"},
+		{"alias case", `[GoLang, GOLANG]`, "[Go]"},
+		{"alias and canonical", `"Go, golang"`, "[Go]"},
+		{"case duplicates", `"Web, web, WEB"`, "[Web]"},
+		{"trailing comma", `"Go, Web,"`, "[Go][Web]"},
+		{"empty entries", `", Go,, ,Web"`, "[Go][Web]"},
+		{"spaces", `"  Open Source  "`, "[Open Source]"},
+		{"empty", `""`, ""},
+	}
+
+	files := map[string]string{
+		"templates/post.html": `<p>tags:{{ range .Tags }}[{{ . }}]{{ end }}</p>`,
+	}
+	for i, test := range tests {
+		files[fmt.Sprintf("posts/tags-%d.md", i)] = fmt.Sprintf("---\nTitle: Tags %d\nDate: 2025-01-01\nTags: %s\n---\n\nText.\n", i, test.tags)
+	}
+	b := &builder.Builder{}
+	b.Config.TagAliases = map[string]string{"golang": "Go"}
+	dir := buildSite(t, b, files)
+
+	for i, test := range tests {
+		html := readOut(t, dir, fmt.Sprintf("post/tags-%d.html", i))
+		if want := "<p>tags:" + test.want + "</p>"; !strings.Contains(html, want) {
+			t.Errorf("%s: Tags: %s: want %q in:\n%s", test.name, test.tags, want, html)
+		}
+	}
+}
+
+func TestTagData(t *testing.T) {
+	post := func(title string, date string, tags string) string {
+		return "---\nTitle: " + title + "\nDate: " + date + "\nTags: " + tags + "\n---\n\nText.\n"
+	}
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"data/tags.yaml":      "Go:\n  DisplayName: Golang\n  Description: Posts about Go.\n  Color: \"#00ADD8\"\nUnused:\n  DisplayName: Never used\n",
+		"templates/tag.html":  `{{ .Tag }}|{{ .TagInfo.DisplayName }}|{{ .TagInfo.Description }}|{{ .TagInfo.Color }}|{{ .TagInfo.URL }}|{{ .TagInfo.Count }}|{{ range .Posts }}{{ .Title }},{{ end }}`,
+		"templates/tags.html": `{{ range .Tags }}{{ .DisplayName }}={{ .URL }}({{ .Count }});{{ end }}`,
+		"posts/demo.md":       post("Older", "2025-01-01", `"Go, Open Source"`),
+		"posts/newer.md":      post("Newer", "2025-02-01", "[Go]"),
+	})
+
+	for file, want := range map[string]string{
+		"tags/go.html":          "Go|Golang|Posts about Go.|#00ADD8|/tags/go|2|Newer,Older,",
+		"tags/open-source.html": "Open Source|Open Source|||/tags/open-source|1|Older,",
+		"tags/index.html":       "Golang=/tags/go(2);Open Source=/tags/open-source(1);",
+	} {
+		if got := readOut(t, dir, file); got != want {
+			t.Errorf("%s:\ngot  %q\nwant %q", file, got, want)
+		}
+	}
+
+	sitemap := readOut(t, dir, "sitemap.xml")
+	for _, want := range []string{"/tags/</loc>", "/tags/go</loc>", "/tags/open-source</loc>"} {
+		if !strings.Contains(sitemap, want) {
+			t.Errorf("missing %q in sitemap.xml:\n%s", want, sitemap)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package builder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func TestTaxonomyPathCollisions(t *testing.T) {
+	err := buildSiteError(t, &builder.Builder{Config: builder.Config{Taxonomies: []builder.TaxonomyConfig{
+		{Name: "Series"},
+		{Name: "Categories"},
+		{Name: "Kinds", Path: "/categories/"},
+	}}}, nil)
+	for _, want := range []string{
+		"Taxonomies: Series would be published under /series, which is reserved",
+		"Taxonomies: Categories and Kinds are both published under /categories",
+	} {
+		if !strings.Contains(err, want) {
+			t.Errorf("missing %q in build error: %s", want, err)
+		}
+	}
+}
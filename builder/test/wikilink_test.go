@@ -0,0 +1,93 @@
+package builder_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kvizdos/easyblog/builder"
+)
+
+func wikiPost(title string, date string, body string) string {
+	return "---\nTitle: " + title + "\nDate: " + date + "\n---\n\n" + body + "\n"
+}
+
+func TestWikiLinks(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{"[[target]]", `<a class="wikilink" href="/post/target">Target Post</a>`},
+		{"[[target|read this]]", `<a class="wikilink" href="/post/target">read this</a>`},
+		{"[[guides/setup]]", `<a class="wikilink" href="/post/guides/setup">Setup Guide</a>`},
+		{"[[setup|by name]]", `<a class="wikilink" href="/post/guides/setup">by name</a>`}, // The only post named setup
+		{"`[[target]]`", `<code>[[target]]</code>`},
+		{"[[]] and [[a\nb]]", "[[]] and [[a\nb]]"},
+	}
+
+	body := []string{}
+	for _, test := range tests {
+		body = append(body, test.markdown)
+	}
+	dir := buildSite(t, &builder.Builder{}, map[string]string{
+		"templates/post.html":   `{{ .Body }}<p>backlinks:{{ range .Backlinks }} {{ .Title }}{{ end }}</p>`,
+		"posts/links.md":        wikiPost("Links", "2025-01-03", strings.Join(body, "\n\n")),
+		"posts/other.md":        wikiPost("Other", "2025-01-02", "See [[target]] and [[target|again]]."),
+		"posts/target.md":       wikiPost("Target Post", "2025-01-01", "Back to [[target|myself]]."),
+		"posts/guides/setup.md": wikiPost("Setup Guide", "2025-01-01", "Text."),
+	})
+
+	html := readOut(t, dir, "post/links.html")
+	for _, test := range tests {
+		if want := "<p>" + test.want + "</p>"; !strings.Contains(html, want) {
+			t.Errorf("%q: missing %q in:\n%s", test.markdown, want, html)
+		}
+	}
+
+	// Newest first, once per post, without the post itself.
+	for file, want := range map[string]string{
+		"post/target.html":       "<p>backlinks: Links Other</p>",
+		"post/guides/setup.html": "<p>backlinks: Links</p>",
+		"post/other.html":        "<p>backlinks:</p>",
+	} {
+		if html := readOut(t, dir, file); !strings.Contains(html, want) {
+			t.Errorf("%s: missing %q in:\n%s", file, want, html)
+		}
+	}
+}
+
+func TestBrokenWikiLinks(t *testing.T) {
+	err := buildSiteError(t, &builder.Builder{}, map[string]string{
+		"posts/links.md":        wikiPost("Links", "2025-01-03", "[[missing]] and [[intro]], but [[guides/intro]] works."),
+		"posts/guides/intro.md": wikiPost("Guides", "2025-01-01", "Text."),
+		"posts/notes/intro.md":  wikiPost("Notes", "2025-01-01", "Text."),
+	})
+	for _, want := range []string{
+		"posts/links.md: unresolved wiki link [[missing]]",
+		"posts/links.md: unresolved wiki link [[intro]]", // Ambiguous: guides/intro or notes/intro
+	} {
+		if !strings.Contains(err, want) {
+			t.Errorf("missing %q in build error: %s", want, err)
+		}
+	}
+}
+
+func TestRebuildAfterBrokenWikiLink(t *testing.T) {
+	// Serve rebuilds with the same Builder after every change, so a failed
+	// build must leave it ready for the next one.
+	dir := copySite(t, map[string]string{
+		"posts/links.md": wikiPost("Links", "2025-01-03", "[[missing]]"),
+	})
+	b := &builder.Builder{}
+	if err := build(t, b, dir); err == nil || !strings.Contains(err.Error(), "unresolved wiki link [[missing]]") {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, "posts", "missing.md"), wikiPost("Missing", "2025-01-01", "Text.")); err != nil {
+		t.Fatal(err)
+	}
+	buildIn(t, b, dir)
+	if html := readOut(t, dir, "post/links.html"); !strings.Contains(html, `href="/post/missing"`) {
+		t.Errorf("link not resolved after the rebuild:\n%s", html)
+	}
+}
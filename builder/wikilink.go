@@ -0,0 +1,195 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Wiki links ([[post-slug]] and [[post-slug|label]]) are parsed while posts
+// are still being read, before every post's URL and title are known. They
+// are rendered with placeholders, which resolveWikiLinks swaps for the
+// target's URL and title once all posts are parsed.
+
+var KindWikiLink = ast.NewNodeKind("WikiLink")
+
+// WikiLink is an inline [[target]] or [[target|label]]. With a label, the
+// label is its child text; without one, the target's title is used.
+type WikiLink struct {
+	ast.BaseInline
+	Target   string
+	HasLabel bool
+}
+
+func (n *WikiLink) Kind() ast.NodeKind { return KindWikiLink }
+
+func (n *WikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Target": n.Target}, nil)
+}
+
+type wikiLinkParser struct{}
+
+func (p *wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	inner := line[2 : 2+end]
+	if len(bytes.TrimSpace(inner)) == 0 || bytes.ContainsAny(inner, "[\n") {
+		return nil
+	}
+
+	node := &WikiLink{Target: string(bytes.TrimSpace(inner))}
+	labelStart, labelEnd := segment.Start+2, segment.Start+2+end
+	if i := bytes.IndexByte(inner, '|'); i >= 0 {
+		node.Target = string(bytes.TrimSpace(inner[:i]))
+		node.HasLabel = true
+		labelStart += i + 1
+	}
+	node.AppendChild(node, ast.NewTextSegment(text.NewSegment(labelStart, labelEnd)))
+
+	block.Advance(2 + end + 2)
+	return node
+}
+
+func wikiLinkPlaceholder(field string, target string) string {
+	return fmt.Sprintf("\x00wikilink-%s:%s\x00", field, target)
+}
+
+var wikiLinkPlaceholderPattern = regexp.MustCompile("\x00wikilink-(url|title):([^\x00]*)\x00")
+
+type wikiLinkRenderer struct{}
+
+func (r *wikiLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindWikiLink, r.render)
+}
+
+func (r *wikiLinkRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*WikiLink)
+	if !entering {
+		_, _ = w.WriteString("</a>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<a class="wikilink" href="` + wikiLinkPlaceholder("url", n.Target) + `">`)
+	if !n.HasLabel {
+		_, _ = w.WriteString(wikiLinkPlaceholder("title", n.Target))
+		return ast.WalkSkipChildren, nil
+	}
+	return ast.WalkContinue, nil
+}
+
+type wikiLinkExtender struct{}
+
+func (e *wikiLinkExtender) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(&wikiLinkParser{}, 199), // before the link parser
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&wikiLinkRenderer{}, 500),
+	))
+}
+
+// collectWikiLinks returns the targets of every wiki link in doc.
+func collectWikiLinks(doc ast.Node) []string {
+	targets := []string{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*WikiLink); ok && entering {
+			targets = append(targets, link.Target)
+		}
+		return ast.WalkContinue, nil
+	})
+	return targets
+}
+
+// postKey is the name a post is linked by: its path inside posts/ without
+// the extension, e.g. my-post or guides/my-post.
+func postKey(slug string) string {
+	return strings.Trim(strings.TrimPrefix(slug, "/post/"), "/")
+}
+
+// buildWikiTargets indexes posts by their full key and, when unambiguous,
+// by the last path segment of the key.
+func buildWikiTargets(posts PostList) map[string]PostMetadata {
+	targets := map[string]PostMetadata{}
+	baseNames := map[string]int{}
+	for _, post := range posts {
+		targets[postKey(post.Slug)] = post
+		baseNames[path.Base(postKey(post.Slug))]++
+	}
+	for _, post := range posts {
+		base := path.Base(postKey(post.Slug))
+		if _, taken := targets[base]; !taken && baseNames[base] == 1 {
+			targets[base] = post
+		}
+	}
+	return targets
+}
+
+// resolveWikiLinks replaces wiki link placeholders in body. Unresolved links
+// are recorded as build errors against sourceFile.
+func (b *Builder) resolveWikiLinks(sourceFile string, body template.HTML) template.HTML {
+	if !strings.Contains(string(body), "\x00wikilink-") {
+		return body
+	}
+
+	resolved := wikiLinkPlaceholderPattern.ReplaceAllStringFunc(string(body), func(match string) string {
+		parts := wikiLinkPlaceholderPattern.FindStringSubmatch(match)
+		field, target := parts[1], parts[2]
+
+		post, ok := b.wikiTargets[target]
+		if !ok {
+			if field == "url" {
+				b.addBuildError(fmt.Errorf("%s: unresolved wiki link [[%s]]", sourceFile, target))
+			}
+			return html.EscapeString(target)
+		}
+		if field == "url" {
+			return html.EscapeString(post.Slug)
+		}
+		return html.EscapeString(post.Title)
+	})
+	return template.HTML(resolved)
+}
+
+// buildBacklinks maps each post's slug to the posts linking to it, newest
+// first.
+func (b *Builder) buildBacklinks(posts []Post) map[string]PostList {
+	linksBySlug := map[string][]string{}
+	for _, post := range posts {
+		linksBySlug[post.Slug] = post.wikiLinks
+	}
+
+	backlinks := map[string]PostList{}
+	for _, source := range b.posts {
+		seen := map[string]bool{}
+		for _, target := range linksBySlug[source.Slug] {
+			targetPost, ok := b.wikiTargets[target]
+			if !ok || seen[targetPost.Slug] || targetPost.Slug == source.Slug {
+				continue
+			}
+			seen[targetPost.Slug] = true
+			backlinks[targetPost.Slug] = append(backlinks[targetPost.Slug], source)
+		}
+	}
+	return backlinks
+}
@@ -2,11 +2,14 @@ package entrypoint
 
 import (
 	"flag"
+	"fmt"
 	"html/template"
+	"os"
 
 	"github.com/golobby/config/v3"
 	"github.com/golobby/config/v3/pkg/feeder"
 	"github.com/kvizdos/easyblog/builder"
+	"github.com/kvizdos/easyblog/linkcheck"
 	"github.com/kvizdos/easyblog/quickstart"
 )
 
@@ -23,9 +26,20 @@ var servePort = flag.String("port", "8080", "Change the default port of the Serv
 type EasyblogOpts struct {
 	CustomFuncs       template.FuncMap
 	CustomOGGenerator builder.OGGeneratorFunc
+	// Markdown adds goldmark extensions, parser/renderer options and AST
+	// transformers to the built-in markdown pipeline.
+	Markdown builder.MarkdownExtensions
+	// Shortcodes are {{< name >}} shortcodes rendered in Go. They take
+	// precedence over templates/shortcodes/<name>.html.
+	Shortcodes map[string]builder.ShortcodeFunc
 }
 
 func Start(opts EasyblogOpts) {
+	if len(os.Args) > 1 && os.Args[1] == "check-links" {
+		checkLinks(os.Args[2:])
+		return
+	}
+
 	flag.Parse()
 
 	if *quickStart {
@@ -33,8 +47,31 @@ func Start(opts EasyblogOpts) {
 		return
 	}
 
+	cfg := loadConfig(*configPath)
+
+	build := builder.Builder{
+		MaxConcurrentPageBuilds: 5,
+		Config:                  cfg,
+		CustomFuncs:             opts.CustomFuncs,
+		OGGenerator:             opts.CustomOGGenerator,
+		Markdown:                opts.Markdown,
+		Shortcodes:              opts.Shortcodes,
+	}
+
+	if *serve == true {
+		build.Serve(*servePort)
+		return
+	}
+
+	if err := build.Build(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig(path string) builder.Config {
 	cfg := builder.Config{}
-	jsonFeeder := feeder.Yaml{Path: *configPath}
+	jsonFeeder := feeder.Yaml{Path: path}
 
 	// Create a Config instance and feed `myConfig` using `jsonFeeder`
 	c := config.New()
@@ -44,18 +81,50 @@ func Start(opts EasyblogOpts) {
 	if err != nil {
 		panic(err)
 	}
+	return cfg
+}
 
-	build := builder.Builder{
-		MaxConcurrentPageBuilds: 5,
-		Config:                  cfg,
-		CustomFuncs:             opts.CustomFuncs,
-		OGGenerator:             opts.CustomOGGenerator,
+// checkLinks runs `easyblog check-links`, checking the already built site
+// and exiting with status 1 when links are broken.
+func checkLinks(args []string) {
+	flags := flag.NewFlagSet("check-links", flag.ExitOnError)
+	configPath := flags.String("config", "config.yaml", "Specify a path to a config file")
+	dir := flags.String("dir", "out", "Directory of the built site")
+	external := flags.Bool("external", false, "Also check external URLs (overrides LinkCheck.External)")
+	jsonPath := flags.String("json", "", "Write the report as JSON to this file, or - for stdout")
+	flags.Parse(args)
+
+	cfg := loadConfig(*configPath)
+	checkOpts := cfg.LinkCheckOptions(*dir)
+	if *external {
+		checkOpts.External = true
 	}
 
-	if *serve == true {
-		build.Serve(*servePort)
-		return
+	report, err := linkcheck.Check(checkOpts)
+	if err != nil {
+		panic(err)
+	}
+
+	if *jsonPath == "-" {
+		out, err := report.JSON()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(out))
+	} else {
+		report.Print(os.Stdout)
+		if *jsonPath != "" {
+			out, err := report.JSON()
+			if err != nil {
+				panic(err)
+			}
+			if err := os.WriteFile(*jsonPath, out, 0644); err != nil {
+				panic(err)
+			}
+		}
 	}
 
-	build.Build()
+	if !report.OK() {
+		os.Exit(1)
+	}
 }
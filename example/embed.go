@@ -8,6 +8,12 @@ var TemplatesContent embed.FS
 //go:embed posts/*
 var PostsContent embed.FS
 
+//go:embed pages/*
+var PagesContent embed.FS
+
+//go:embed data/*
+var DataContent embed.FS
+
 //go:embed og/*
 var OgContent embed.FS
 
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type Author struct {
+	Name string `xml:"name"`
+}
+
+type Entry struct {
+	XMLName   xml.Name `xml:"entry"`
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Link      Link     `xml:"link"`
+	Published string   `xml:"published,omitempty"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary,omitempty"`
+	Author    *Author  `xml:"author,omitempty"`
+}
+
+// Feed is an Atom feed. ID and the alternate link are BaseURL + Path; the
+// self link is BaseURL + FeedPath.
+type Feed struct {
+	XMLName xml.Name `xml:"feed"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Entries []Entry  `xml:"entry"`
+
+	BaseURL string `xml:"-"`
+
+	mu      sync.Mutex
+	updated time.Time
+}
+
+func New(baseURL string, title string, path string, feedPath string) *Feed {
+	return &Feed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   title,
+		ID:      fmt.Sprintf("%s%s", baseURL, path),
+		BaseURL: baseURL,
+		Links: []Link{
+			{Href: fmt.Sprintf("%s%s", baseURL, feedPath), Rel: "self"},
+			{Href: fmt.Sprintf("%s%s", baseURL, path), Rel: "alternate"},
+		},
+	}
+}
+
+// AddEntry adds an entry for pageURL. A zero updated falls back to published.
+func (f *Feed) AddEntry(title string, pageURL string, author string, summary string, published time.Time, updated time.Time) {
+	if updated.IsZero() {
+		updated = published
+	}
+
+	entry := Entry{
+		Title:   title,
+		ID:      fmt.Sprintf("%s%s", f.BaseURL, pageURL),
+		Link:    Link{Href: fmt.Sprintf("%s%s", f.BaseURL, pageURL)},
+		Updated: updated.Format(time.RFC3339),
+		Summary: summary,
+	}
+	if !published.IsZero() {
+		entry.Published = published.Format(time.RFC3339)
+	}
+	if author != "" {
+		entry.Author = &Author{Name: author}
+	}
+
+	f.mu.Lock()
+	f.Entries = append(f.Entries, entry)
+	if updated.After(f.updated) {
+		f.updated = updated
+	}
+	f.mu.Unlock()
+}
+
+func (f *Feed) Marshal() []byte {
+	f.Updated = f.updated.Format(time.RFC3339)
+	out, err := xml.MarshalIndent(f, " ", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append([]byte(xml.Header), out...)
+}
@@ -0,0 +1,42 @@
+package feed_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/kvizdos/easyblog/feed"
+)
+
+func TestFeed(t *testing.T) {
+	f := feed.New("https://example.com", "Categories: Go", "/categories/go", "/categories/go.xml")
+
+	published := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	f.AddEntry("First", "/post/first", "Kenton", "A summary", published, time.Time{})
+	f.AddEntry("Second", "/post/second", "", "", published, updated)
+
+	var parsed struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(f.Marshal(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Updated != "2025-04-01T00:00:00Z" {
+		t.Errorf("feed updated = %q, want the newest entry", parsed.Updated)
+	}
+	if len(parsed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(parsed.Entries))
+	}
+	if parsed.Entries[0].ID != "https://example.com/post/first" {
+		t.Errorf("entry id = %q", parsed.Entries[0].ID)
+	}
+	if parsed.Entries[0].Updated != "2025-03-15T00:00:00Z" {
+		t.Errorf("entry without updated should fall back to published, got %q", parsed.Entries[0].Updated)
+	}
+}
@@ -1,4 +1,4 @@
 #!/bin/bash
 for i in {1..100}; do
-  cp "./example/posts/How-to-use-Cloudflare-Turnstile-with-Lit-Components.md" "./example/posts/How-to-use-Cloudflare-Turnstile-with-Lit-Components-$i.md"
+  cp "./example/posts/demo.md" "./example/posts/demo-$i.md"
 done
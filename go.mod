@@ -3,25 +3,30 @@ module github.com/kvizdos/easyblog
 go 1.24.0
 
 require (
-	github.com/BurntSushi/toml v1.2.1 // indirect
-	github.com/alecthomas/chroma/v2 v2.2.0 // indirect
+	github.com/BurntSushi/toml v1.2.1
+	github.com/alecthomas/chroma/v2 v2.2.0
+	github.com/bep/goat v0.5.0
+	github.com/fogleman/gg v1.3.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/golobby/config/v3 v3.4.2
+	github.com/mangoumbrella/goldmark-figure v1.2.0
+	github.com/stefanfritsch/goldmark-fences v1.0.0
+	github.com/wyatt915/treeblood v0.1.16
+	github.com/yuin/goldmark v1.7.8
+	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
+	github.com/yuin/goldmark-meta v1.1.0
+	go.abhg.dev/goldmark/anchor v0.2.0
+	go.abhg.dev/goldmark/toc v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/dlclark/regexp2 v1.7.0 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/golobby/cast v1.3.3 // indirect
-	github.com/golobby/config/v3 v3.4.2 // indirect
 	github.com/golobby/dotenv v1.3.2 // indirect
 	github.com/golobby/env/v2 v2.2.4 // indirect
-	github.com/mangoumbrella/goldmark-figure v1.2.0 // indirect
-	github.com/stefanfritsch/goldmark-fences v1.0.0 // indirect
-	github.com/yuin/goldmark v1.7.8 // indirect
-	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc // indirect
-	github.com/yuin/goldmark-meta v1.1.0 // indirect
-	go.abhg.dev/goldmark/anchor v0.2.0 // indirect
-	go.abhg.dev/goldmark/toc v0.11.0 // indirect
 	golang.org/x/image v0.25.0 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,178 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheEntry is an external check result stored in Options.CacheFile.
+type cacheEntry struct {
+	Status    int       `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+func (e cacheEntry) ok() bool {
+	return e.Error == "" && e.Status < 400
+}
+
+func (e cacheEntry) reason() string {
+	if e.Error != "" {
+		return e.Error
+	}
+	return fmt.Sprintf("HTTP %d", e.Status)
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadCache(file string) map[string]cacheEntry {
+	cache := map[string]cacheEntry{}
+	if file == "" {
+		return cache
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return cache
+	}
+	// A corrupt cache is simply rebuilt.
+	_ = json.Unmarshal(content, &cache)
+	return cache
+}
+
+func saveCache(file string, cache map[string]cacheEntry) error {
+	if file == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0644)
+}
+
+// checkExternal requests every external URL once, at most opts.Concurrency
+// at a time, and records failures against every page linking to them.
+func checkExternal(opts Options, external map[string][]string, report *Report) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ttl := opts.CacheTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	cache := loadCache(opts.CacheFile)
+	client := &http.Client{Timeout: timeout}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	results := map[string]cacheEntry{}
+
+	for link := range external {
+		target, _ := url.Parse(link)
+		if (len(opts.AllowDomains) > 0 && !matchesDomain(target.Hostname(), opts.AllowDomains)) ||
+			matchesDomain(target.Hostname(), opts.DenyDomains) {
+			report.ExternalSkipped++
+			continue
+		}
+		if entry, ok := cache[link]; ok && entry.ok() && time.Since(entry.CheckedAt) < ttl {
+			report.ExternalFromCache++
+			results[link] = entry
+			continue
+		}
+
+		report.ExternalChecked++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			entry := request(client, link)
+			<-slots
+
+			// Only working URLs are cached: failures are often temporary
+			// (timeouts, 5xx) and are checked again on the next run.
+			mu.Lock()
+			results[link] = entry
+			if entry.ok() {
+				cache[link] = entry
+			} else {
+				delete(cache, link)
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if err := saveCache(opts.CacheFile, cache); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write link check cache: %v\n", err)
+	}
+
+	for link, entry := range results {
+		if entry.ok() {
+			continue
+		}
+		pages := external[link]
+		sort.Strings(pages)
+		for _, page := range pages {
+			report.Broken = append(report.Broken, Broken{page, link, entry.reason()})
+		}
+	}
+}
+
+// request checks a URL with HEAD, falling back to GET for servers that
+// reject HEAD.
+func request(client *http.Client, link string) cacheEntry {
+	entry := cacheEntry{CheckedAt: time.Now()}
+	status, err := send(client, http.MethodHead, link)
+	if err == nil && status >= 400 {
+		status, err = send(client, http.MethodGet, link)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			entry.Error = urlErr.Err.Error()
+		}
+		return entry
+	}
+	entry.Status = status
+	return entry
+}
+
+func send(client *http.Client, method string, link string) (int, error) {
+	req, err := http.NewRequest(method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "easyblog-linkcheck")
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
@@ -0,0 +1,250 @@
+package linkcheck
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Options controls a link check of a rendered site.
+type Options struct {
+	Dir     string    // Rendered site, usually out/
+	BaseURL string    // Absolute links under this URL are checked as internal links
+	Since   time.Time // When set, files in Dir last modified before it are left over from earlier builds and ignored
+
+	External     bool          // Also request external http(s) URLs
+	Concurrency  int           // Parallel external requests, defaults to 8
+	Timeout      time.Duration // Per request, defaults to 10s
+	AllowDomains []string      // When set, only these domains (and their subdomains) are requested
+	DenyDomains  []string      // Never requested
+	CacheFile    string        // JSON file remembering working external URLs between runs
+	CacheTTL     time.Duration // How long cached results are trusted, defaults to 24h
+}
+
+// Broken is a link that does not resolve.
+type Broken struct {
+	Page   string `json:"page"` // File the link was found in, relative to Options.Dir
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Pages             int      `json:"pages"`
+	InternalLinks     int      `json:"internalLinks"`
+	ExternalLinks     int      `json:"externalLinks"`
+	ExternalChecked   int      `json:"externalChecked"`
+	ExternalSkipped   int      `json:"externalSkipped"`
+	ExternalFromCache int      `json:"externalFromCache"`
+	Broken            []Broken `json:"broken"`
+}
+
+// OK reports whether no broken links were found.
+func (r *Report) OK() bool {
+	return len(r.Broken) == 0
+}
+
+var (
+	ignoredPattern = regexp.MustCompile(`(?is)<!--.*?-->|<script\b.*?</script>|<style\b.*?</style>`)
+	tagPattern     = regexp.MustCompile(`(?s)<[a-zA-Z][^>]*>`)
+	attrPattern    = regexp.MustCompile(`(?s)\s([a-zA-Z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// page is a rendered HTML file with its links and the IDs its anchors can
+// point at.
+type page struct {
+	file  string // Relative to Options.Dir, slash separated
+	links []string
+	ids   map[string]bool
+}
+
+// parsePage extracts href/src links and id/name anchors from an HTML file.
+func parsePage(file string, content string) page {
+	p := page{file: file, ids: map[string]bool{}}
+	content = ignoredPattern.ReplaceAllString(content, "")
+	for _, tag := range tagPattern.FindAllString(content, -1) {
+		isAnchor := strings.HasPrefix(strings.ToLower(tag), "<a ")
+		for _, attr := range attrPattern.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(attr[2] + attr[3] + attr[4])
+			switch strings.ToLower(attr[1]) {
+			case "href", "src":
+				p.links = append(p.links, value)
+			case "id":
+				p.ids[value] = true
+			case "name":
+				if isAnchor {
+					p.ids[value] = true
+				}
+			}
+		}
+	}
+	return p
+}
+
+// urlPath is the path a file is served at: out/a/b.html at /a/b and
+// out/a/b/index.html at /a/b/.
+func urlPath(file string) string {
+	if file == "index.html" {
+		return "/"
+	}
+	if strings.HasSuffix(file, "/index.html") {
+		return "/" + strings.TrimSuffix(file, "index.html")
+	}
+	return "/" + strings.TrimSuffix(file, ".html")
+}
+
+// Check walks every HTML file under opts.Dir and reports the links that do
+// not resolve.
+func Check(opts Options) (*Report, error) {
+	pages := map[string]page{}
+	files := map[string]bool{}
+	err := filepath.WalkDir(opts.Dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !opts.Since.IsZero() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.ModTime().Before(opts.Since) {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(opts.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files[rel] = true
+		if !strings.HasSuffix(rel, ".html") {
+			return nil
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		pages[rel] = parsePage(rel, string(content))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A site published below a path (https://example.com/blog/) links to
+	// its own pages with that path, which isn't part of Dir.
+	var siteHost, basePath string
+	if opts.BaseURL != "" {
+		if base, err := url.Parse(opts.BaseURL); err == nil {
+			siteHost = base.Host
+			basePath = strings.TrimSuffix(base.Path, "/")
+		}
+	}
+
+	report := &Report{Pages: len(pages), Broken: []Broken{}}
+	external := map[string][]string{} // URL -> pages linking to it
+
+	for _, p := range pages {
+		pageURL := &url.URL{Path: urlPath(p.file)}
+		for _, link := range p.links {
+			link = strings.TrimSpace(link)
+			if link == "" || link == "#" {
+				continue
+			}
+			target, err := url.Parse(link)
+			if err != nil {
+				report.Broken = append(report.Broken, Broken{p.file, link, fmt.Sprintf("invalid URL: %v", err)})
+				continue
+			}
+
+			switch {
+			case target.Scheme == "http" || target.Scheme == "https" || (target.Scheme == "" && target.Host != ""):
+				path, inSite := sitePath(basePath, target.Path)
+				if siteHost == "" || target.Host != siteHost || !inSite {
+					report.ExternalLinks++
+					if target.Scheme == "" {
+						target.Scheme = "https"
+					}
+					target.Fragment = ""
+					external[target.String()] = append(external[target.String()], p.file)
+					continue
+				}
+				target.Path, target.RawPath = path, ""
+			case target.Scheme != "":
+				// mailto:, tel:, data:, javascript: and friends.
+				continue
+			case strings.HasPrefix(target.Path, "/"):
+				if path, inSite := sitePath(basePath, target.Path); inSite {
+					target.Path, target.RawPath = path, ""
+				}
+			}
+
+			report.InternalLinks++
+			resolved := pageURL.ResolveReference(target)
+			file, ok := resolveFile(files, resolved.Path)
+			if !ok {
+				report.Broken = append(report.Broken, Broken{p.file, link, "no such file"})
+				continue
+			}
+			if target.Fragment == "" {
+				continue
+			}
+			targetPage, isPage := pages[file]
+			if isPage && !targetPage.ids[target.Fragment] {
+				report.Broken = append(report.Broken, Broken{p.file, link, fmt.Sprintf("no element with id %q in %s", target.Fragment, file)})
+			}
+		}
+	}
+
+	if opts.External {
+		checkExternal(opts, external, report)
+	} else {
+		report.ExternalSkipped = len(external)
+	}
+
+	sort.Slice(report.Broken, func(i, j int) bool {
+		if report.Broken[i].Page != report.Broken[j].Page {
+			return report.Broken[i].Page < report.Broken[j].Page
+		}
+		return report.Broken[i].URL < report.Broken[j].URL
+	})
+	return report, nil
+}
+
+// sitePath returns urlPath relative to the site's basePath, and whether it
+// lies below it at all.
+func sitePath(basePath string, urlPath string) (string, bool) {
+	switch {
+	case basePath == "":
+		return urlPath, true
+	case urlPath == basePath:
+		return "/", true
+	case strings.HasPrefix(urlPath, basePath+"/"):
+		return strings.TrimPrefix(urlPath, basePath), true
+	}
+	return urlPath, false
+}
+
+// resolveFile finds the file served at urlPath, the same way Serve does:
+// /a/b may be a/b, a/b.html or a/b/index.html, /a/b/ is a/b/index.html.
+func resolveFile(files map[string]bool, urlPath string) (string, bool) {
+	clean := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	candidates := []string{clean, clean + ".html", path.Join(clean, "index.html")}
+	if strings.HasSuffix(urlPath, "/") || clean == "" {
+		candidates = []string{path.Join(clean, "index.html")}
+	}
+	for _, candidate := range candidates {
+		if files[candidate] {
+			return candidate, true
+		}
+	}
+	return "", false
+}
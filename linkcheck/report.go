@@ -0,0 +1,27 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Print writes a human readable summary of the report to w.
+func (r *Report) Print(w io.Writer) {
+	for _, broken := range r.Broken {
+		fmt.Fprintf(w, "%s: %s (%s)\n", broken.Page, broken.URL, broken.Reason)
+	}
+	fmt.Fprintf(w, "Checked %d internal and %d external link(s) in %d page(s)", r.InternalLinks, r.ExternalLinks, r.Pages)
+	if r.ExternalSkipped > 0 {
+		fmt.Fprintf(w, ", %d external URL(s) skipped", r.ExternalSkipped)
+	}
+	if r.ExternalFromCache > 0 {
+		fmt.Fprintf(w, ", %d from cache", r.ExternalFromCache)
+	}
+	fmt.Fprintf(w, ": %d broken\n", len(r.Broken))
+}
+
+// JSON returns the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
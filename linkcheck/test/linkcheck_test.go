@@ -0,0 +1,185 @@
+package linkcheck_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kvizdos/easyblog/linkcheck"
+)
+
+func writeSite(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInternalLinks(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"index.html": `<a href="/post/one#intro">ok</a> <a href="/post/one#nope">bad anchor</a>
+			<a href="/post/missing">missing</a> <a href="/tags/">tags</a> <a href="mailto:a@b.c">mail</a>
+			<a href="https://example.com/post/one">own site</a>`,
+		"post/one.html":   `<h2 id="intro">Intro</h2><img src="../assets/logo.png"><a href="#intro">top</a>`,
+		"tags/index.html": `<a href='../post/one'>one</a><!-- <a href="/commented-out"> -->`,
+		"assets/logo.png": "",
+	})
+
+	report, err := linkcheck.Check(linkcheck.Options{Dir: dir, BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, broken := range report.Broken {
+		got = append(got, broken.Page+" "+broken.URL)
+	}
+	want := []string{"index.html /post/missing", "index.html /post/one#nope"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("broken = %v, want %v", got, want)
+	}
+	if report.InternalLinks != 8 {
+		t.Errorf("internal links = %d, want 8", report.InternalLinks)
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := writeSite(t, map[string]string{
+		"index.html": `<a href="` + server.URL + `/ok">ok</a> <a href="` + server.URL + `/gone">gone</a>
+			<a href="https://denied.example/x">denied</a>`,
+	})
+	opts := linkcheck.Options{
+		Dir:         dir,
+		External:    true,
+		DenyDomains: []string{"example"},
+		CacheFile:   filepath.Join(t.TempDir(), "cache.json"),
+	}
+
+	report, err := linkcheck.Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Broken) != 1 || report.Broken[0].URL != server.URL+"/gone" {
+		t.Fatalf("broken = %+v", report.Broken)
+	}
+	if report.ExternalSkipped != 1 {
+		t.Errorf("skipped = %d, want 1", report.ExternalSkipped)
+	}
+
+	// HEAD to /gone fails and is retried with GET.
+	if requests.Load() != 3 {
+		t.Errorf("requests = %d, want 3", requests.Load())
+	}
+
+	report, err = linkcheck.Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only /ok is cached; /gone is requested again.
+	if requests.Load() != 5 || report.ExternalFromCache != 1 || len(report.Broken) != 1 {
+		t.Errorf("second run: requests = %d, from cache = %d, broken = %d", requests.Load(), report.ExternalFromCache, len(report.Broken))
+	}
+}
+
+func TestBaseURLPath(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"index.html": `<a href="https://example.com/blog/post/one#intro">ok</a> <a href="/blog/post/one">ok</a>
+			<a href="https://example.com/blog">home</a> <a href="https://example.com/blog/post/missing">missing</a>
+			<a href="https://example.com/other/">another site</a>`,
+		"post/one.html": `<h2 id="intro">Intro</h2>`,
+	})
+
+	report, err := linkcheck.Check(linkcheck.Options{Dir: dir, BaseURL: "https://example.com/blog/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Broken) != 1 || report.Broken[0].URL != "https://example.com/blog/post/missing" {
+		t.Fatalf("broken = %+v", report.Broken)
+	}
+	if report.InternalLinks != 4 || report.ExternalLinks != 1 {
+		t.Errorf("internal, external links = %d, %d, want 4, 1", report.InternalLinks, report.ExternalLinks)
+	}
+}
+
+func TestFailuresAreNotCached(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Down for the first run's HEAD and GET.
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dir := writeSite(t, map[string]string{
+		"index.html": `<a href="` + server.URL + `/flaky">flaky</a>`,
+	})
+	opts := linkcheck.Options{Dir: dir, External: true, CacheFile: filepath.Join(t.TempDir(), "cache.json")}
+
+	report, err := linkcheck.Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Broken) != 1 {
+		t.Fatalf("first run: broken = %+v", report.Broken)
+	}
+
+	report, err = linkcheck.Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Broken) != 0 || report.ExternalChecked != 1 || report.ExternalFromCache != 0 {
+		t.Errorf("second run: broken = %+v, checked = %d, from cache = %d", report.Broken, report.ExternalChecked, report.ExternalFromCache)
+	}
+
+	report, err = linkcheck.Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ExternalFromCache != 1 || requests.Load() != 3 {
+		t.Errorf("third run: from cache = %d, requests = %d, want 1, 3", report.ExternalFromCache, requests.Load())
+	}
+}
+
+func TestSinceIgnoresStaleFiles(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"index.html":    `<a href="/post/one">kept</a> <a href="/post/removed">removed</a>`,
+		"post/one.html": `one`,
+		// Left over from an earlier build.
+		"post/removed.html": `<a href="/post/gone">gone</a>`,
+	})
+	since := time.Now().Add(-time.Minute)
+	earlier := since.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "post", "removed.html"), earlier, earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := linkcheck.Check(linkcheck.Options{Dir: dir, Since: since})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Broken) != 1 || report.Broken[0].URL != "/post/removed" || report.Pages != 2 {
+		t.Errorf("pages = %d, broken = %+v", report.Pages, report.Broken)
+	}
+}
@@ -53,6 +53,8 @@ func Scaffold(targetDir string) error {
 	dirs := map[string]embed.FS{
 		"templates": embedded_example.TemplatesContent,
 		"posts":     embedded_example.PostsContent,
+		"pages":     embedded_example.PagesContent,
+		"data":      embedded_example.DataContent,
 		"og":        embedded_example.OgContent,
 		"assets":    embedded_example.AssetsContent,
 		".github":   embedded_example.GitHubContent,
@@ -4,11 +4,13 @@ import (
 	"encoding/xml"
 	"fmt"
 	"sync"
+	"time"
 )
 
 type SitemapPage struct {
 	XMLName  xml.Name `xml:"url"`
 	Location string   `xml:"loc"`
+	LastMod  string   `xml:"lastmod,omitempty"`
 }
 
 type Sitemap struct {
@@ -30,6 +32,21 @@ func (s *Sitemap) AddPageURL(pageURL string) {
 	s.mu.Unlock()
 }
 
+// AddPageURLWithLastMod adds a page with a <lastmod>. A zero lastMod is
+// left out.
+func (s *Sitemap) AddPageURLWithLastMod(pageURL string, lastMod time.Time) {
+	page := SitemapPage{
+		Location: fmt.Sprintf("%s%s", s.BaseURL, pageURL),
+	}
+	if !lastMod.IsZero() {
+		page.LastMod = lastMod.Format(time.RFC3339)
+	}
+
+	s.mu.Lock()
+	s.Pages = append(s.Pages, page)
+	s.mu.Unlock()
+}
+
 func (s *Sitemap) Marshal() []byte {
 	out, err := xml.MarshalIndent(s, " ", "  ")
 	if err != nil {
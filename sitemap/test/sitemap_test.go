@@ -3,7 +3,9 @@ package sitemap_test
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kvizdos/easyblog/sitemap"
 )
@@ -21,3 +23,21 @@ func TestMain(t *testing.T) {
 	out, _ := xml.MarshalIndent(sm, " ", "  ")
 	fmt.Println(string(out))
 }
+
+func TestLastMod(t *testing.T) {
+	sm := &sitemap.Sitemap{
+		BaseURL: "https://example.com",
+		Pages:   []sitemap.SitemapPage{},
+	}
+
+	sm.AddPageURLWithLastMod("/post/a", time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC))
+	sm.AddPageURLWithLastMod("/post/b", time.Time{})
+
+	out := string(sm.Marshal())
+	if !strings.Contains(out, "<lastmod>2025-03-15T10:00:00Z</lastmod>") {
+		t.Errorf("expected lastmod for /post/a, got:\n%s", out)
+	}
+	if strings.Count(out, "<lastmod>") != 1 {
+		t.Errorf("expected a zero lastMod to be omitted, got:\n%s", out)
+	}
+}